@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 
+	"github.com/stsg/gophermart2/internal/config"
 	"github.com/stsg/gophermart2/internal/logger"
 	"github.com/stsg/gophermart2/internal/server"
 	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"github.com/stsg/gophermart2/internal/tracing"
 )
 
 func main() {
 	logger.New()
+	config.ReloadOnSignal()
+	tracing.New(context.Background())
 	server.Run(context.Background())
+	server.RunAdmin(context.Background())
 	<-shutdowner.Get().ChShutdowned
 }
@@ -1,17 +1,31 @@
 package accrual
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/stsg/gophermart2/internal/config"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/metrics"
 	"github.com/stsg/gophermart2/internal/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-const httpClientTimeout = time.Minute
+const (
+	// defaultRetryAfter is used when a 429 response has no valid Retry-After header.
+	defaultRetryAfter = time.Minute
+)
+
+// orderInfo is the accrual system response body.
+type orderInfo struct {
+	Order   string                       `json:"order"`
+	Status  models.ExternalAccrualStatus `json:"status"`
+	Accrual *models.Money                `json:"accrual"`
+}
 
 type Client struct {
 	*http.Client
@@ -20,34 +34,109 @@ type Client struct {
 func New() Client {
 	return Client{
 		Client: &http.Client{
-			Timeout: httpClientTimeout,
+			Timeout: config.Get().AccrualTimeout,
+			// spans every request and injects the trace context into its headers
+			Transport: otelhttp.NewTransport(http.DefaultTransport,
+				otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+					return "accrual " + r.Method
+				}),
+			),
 		},
 	}
 }
 
-func (c *Client) GetOrderInfo(order models.Order) (res models.Order, err error) {
-	req, err := c.buildRequest(order.ID)
+// GetOrderInfo requests the accrual calculation for the order and returns
+// the order moved to the reported status.
+//
+// It returns ErrOrderNotRegistered on 204, *TooManyRequestsError on 429,
+// ErrInternalServer on 5xx and ErrUnexpectedStatus on any other non-200 status.
+// Unknown statuses and transitions out of a final status are rejected with
+// the corresponding models errors. On any error the order is returned unchanged.
+func (c *Client) GetOrderInfo(ctx context.Context, order models.Order) (res models.Order, err error) {
+	req, err := c.buildRequest(ctx, order.ID)
 	if err != nil {
-		return
+		return order, err
 	}
 
+	start := time.Now()
 	resp, err := c.Do(req)
+	metrics.AccrualRequestDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
-		return
+		metrics.AccrualRequests.WithLabelValues("error").Inc()
+		return order, err
 	}
 	defer resp.Body.Close()
+	metrics.AccrualRequests.WithLabelValues(outcome(resp.StatusCode)).Inc()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNoContent:
+		return order, ErrOrderNotRegistered
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return order, &TooManyRequestsError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return order, fmt.Errorf("%w: %s", ErrInternalServer, resp.Status)
+	default:
+		return order, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	var info orderInfo
+	if err = json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return order, err
+	}
 
 	res = order
-	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err = res.Transition(info.Status, info.Accrual); err != nil {
+		return order, err
+	}
 	return
 }
 
-func (c *Client) buildRequest(orderID string) (req *http.Request, err error) {
+// Ping checks that the accrual system answers HTTP requests, whatever the status.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, config.Get().AccrualAddress, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) buildRequest(ctx context.Context, orderID string) (req *http.Request, err error) {
 	uri, err := url.Parse(config.Get().AccrualAddress)
 	if err != nil {
 		return
 	}
 
-	req, err = http.NewRequest(http.MethodGet, fmt.Sprintf(uri.String()+"/api/orders/%s", orderID), nil)
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(uri.String()+"/api/orders/%s", orderID), nil)
 	return
 }
+
+// outcome groups response statuses for metrics by the way they are handled.
+func outcome(statusCode int) string {
+	switch {
+	case statusCode == http.StatusOK, statusCode == http.StatusNoContent, statusCode == http.StatusTooManyRequests:
+		return strconv.Itoa(statusCode)
+	case statusCode >= http.StatusInternalServerError:
+		return "5xx"
+	default:
+		return "other"
+	}
+}
+
+// parseRetryAfter supports both forms of the header: delay in seconds and HTTP-date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+		return 0
+	}
+	return defaultRetryAfter
+}
@@ -0,0 +1,173 @@
+package accrual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// newTestClient returns a client of the accrual system served by handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := config.Defaults()
+	cfg.AccrualAddress = server.URL
+	cfg.AccrualTimeout = time.Second
+	config.Set(cfg)
+	return New()
+}
+
+func TestGetOrderInfo(t *testing.T) {
+	accrual := models.Money(72998)
+	order := models.Order{ID: "79927398713", UID: "user", AccrualStatus: models.AccrualStatusNew}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    models.Order
+		wantErr error
+	}{
+		{
+			name: "processed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/api/orders/79927398713" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"order":"79927398713","status":"PROCESSED","accrual":729.98}`))
+			},
+			want: models.Order{ID: order.ID, UID: order.UID, AccrualStatus: models.AccrualStatusProcessed, Accrual: &accrual},
+		},
+		{
+			name: "processing",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"order":"79927398713","status":"PROCESSING"}`))
+			},
+			want: models.Order{ID: order.ID, UID: order.UID, AccrualStatus: models.AccrualStatusProcessing},
+		},
+		{
+			name: "unknown status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"order":"79927398713","status":"LOST"}`))
+			},
+			want:    order,
+			wantErr: models.ErrUnknownAccrualStatus,
+		},
+		{
+			name: "not registered",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			want:    order,
+			wantErr: ErrOrderNotRegistered,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			want:    order,
+			wantErr: ErrInternalServer,
+		},
+		{
+			name: "unexpected status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			want:    order,
+			wantErr: ErrUnexpectedStatus,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler)
+			got, err := c.GetOrderInfo(context.Background(), order)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetOrderInfo error %v, want %v", err, tt.wantErr)
+			}
+			if got.ID != tt.want.ID || got.UID != tt.want.UID || got.AccrualStatus != tt.want.AccrualStatus ||
+				(got.Accrual == nil) != (tt.want.Accrual == nil) || got.Accrual != nil && *got.Accrual != *tt.want.Accrual {
+				t.Fatalf("GetOrderInfo = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOrderInfoTooManyRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"seconds", "30", 30 * time.Second, 30 * time.Second},
+		{"HTTP date", time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat), time.Minute, 2 * time.Minute},
+		{"missing", "", defaultRetryAfter, defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			})
+
+			_, err := c.GetOrderInfo(context.Background(), models.Order{ID: "79927398713"})
+			var tooManyRequests *TooManyRequestsError
+			if !errors.As(err, &tooManyRequests) {
+				t.Fatalf("GetOrderInfo error %v, want *TooManyRequestsError", err)
+			}
+			if tooManyRequests.RetryAfter < tt.min || tooManyRequests.RetryAfter > tt.max {
+				t.Fatalf("RetryAfter = %v, want between %v and %v", tooManyRequests.RetryAfter, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestGetOrderInfoTransportError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	cfg := *config.Get()
+	cfg.AccrualAddress = "http://127.0.0.1:0"
+	config.Set(&cfg)
+
+	order := models.Order{ID: "79927398713", UID: "user", AccrualStatus: models.AccrualStatusNew}
+	got, err := c.GetOrderInfo(context.Background(), order)
+	if err == nil {
+		t.Fatal("GetOrderInfo succeeded without the accrual system")
+	}
+	if got.ID != order.ID {
+		t.Fatalf("GetOrderInfo = %+v, want the order unchanged", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"0", 0, 0},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{"", defaultRetryAfter, defaultRetryAfter},
+		{"soon", defaultRetryAfter, defaultRetryAfter},
+		{"-5", defaultRetryAfter, defaultRetryAfter},
+		{"1.5", defaultRetryAfter, defaultRetryAfter},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 59 * time.Minute, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
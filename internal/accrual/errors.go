@@ -0,0 +1,23 @@
+package accrual
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrOrderNotRegistered = errors.New("accrual: order is not registered")
+	ErrInternalServer     = errors.New("accrual: internal server error")
+	ErrUnexpectedStatus   = errors.New("accrual: unexpected response status")
+)
+
+// TooManyRequestsError is returned when the accrual system responds with 429.
+// RetryAfter holds the window during which no more requests should be sent.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("accrual: too many requests, retry after %s", e.RetryAfter)
+}
@@ -1,37 +1,103 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/stsg/gophermart2/internal/config"
 	"github.com/stsg/gophermart2/internal/models"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const TokenExpTime = 15 * time.Minute
+const (
+	refreshTokenSize = 32
+)
+
+// Claims are the access token claims needed by the application.
+type Claims struct {
+	UID        string
+	JTI        string
+	Generation int64
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+}
 
 func GenerateToken(user models.User) (signedToken string, err error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	key, err := Keys().Signing()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(key.Method, jwt.MapClaims{
 		"uid": user.ID,
-		"exp": time.Now().Add(TokenExpTime).Unix(),
+		"jti": uuid.NewString(),
+		"gen": user.TokenGeneration,
+		"iat": now.Unix(),
+		"exp": now.Add(config.Get().AccessTokenTTL).Unix(),
 	})
-	signedToken, err = token.SignedString([]byte(config.Get().SecretToken))
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	signedToken, err = token.SignedString(key.private)
 	return
 }
 
-func GetUIDFromValidToken(signedToken string) (string, error) {
+func ParseToken(signedToken string) (Claims, error) {
 	token, err := jwt.Parse(signedToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.Get().SecretToken), nil
+		kid, _ := token.Header["kid"].(string)
+		key, err := Keys().Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, models.ErrInvalidBearerToken
+		}
+		return key.public, nil
 	})
 	if err != nil {
-		return "", err
+		return Claims{}, err
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", models.ErrInvalidBearerToken
+		return Claims{}, models.ErrInvalidBearerToken
+	}
+
+	uid, _ := claims["uid"].(string)
+	jti, _ := claims["jti"].(string)
+	gen, _ := claims["gen"].(float64)
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+	if uid == "" || jti == "" {
+		return Claims{}, models.ErrInvalidBearerToken
+	}
+	return Claims{
+		UID:        uid,
+		JTI:        jti,
+		Generation: int64(gen),
+		IssuedAt:   time.Unix(int64(iat), 0),
+		ExpiresAt:  time.Unix(int64(exp), 0),
+	}, nil
+}
+
+// GenerateRefreshToken returns an opaque refresh token and the hash to persist.
+func GenerateRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err = rand.Read(b); err != nil {
+		return
 	}
-	return claims["uid"].(string), nil
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func Authenticate(dbUser models.User, reqUser models.User) bool {
@@ -40,3 +106,30 @@ func Authenticate(dbUser models.User, reqUser models.User) bool {
 	}
 	return true
 }
+
+// NewTokenPair issues an access token and a refresh token of the given family.
+// The returned models.RefreshToken has to be persisted by the caller.
+func NewTokenPair(user models.User, familyID string) (pair models.TokenPair, refresh models.RefreshToken, err error) {
+	accessToken, err := GenerateToken(user)
+	if err != nil {
+		return
+	}
+	refreshToken, hash, err := GenerateRefreshToken()
+	if err != nil {
+		return
+	}
+
+	pair = models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(config.Get().AccessTokenTTL.Seconds()),
+	}
+	refresh = models.RefreshToken{
+		ID:        uuid.NewString(),
+		FamilyID:  familyID,
+		UID:       user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(config.Get().RefreshTokenTTL),
+	}
+	return
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNoSigningKey   = errors.New("auth: no signing key")
+	ErrUnknownKeyID   = errors.New("auth: unknown key id")
+	ErrUnsupportedKey = errors.New("auth: unsupported key type")
+)
+
+var (
+	onceKeys sync.Once
+	keys     *KeySet
+)
+
+// Key is a token signing key. HMAC keys have no ID and are never published.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// KeySet holds every key accepted for token verification and the one used
+// for signing.
+//
+// Asymmetric keys are PEM private keys (RSA or Ed25519, PKCS#1 or PKCS#8)
+// stored in config.TokenKeysDir, the file name without extension is the key
+// ID. The directory is re-read every config.TokenKeyRotationInterval: new
+// files become available and the last key ID in lexical order (or the pinned
+// config.TokenSigningKeyID) is used for signing, so a key is rotated by adding
+// a newer file and removed once tokens signed by the old one have expired.
+//
+// Without a keys directory tokens are signed with the HMAC config.SecretToken.
+type KeySet struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+// Keys returns the application key set, loading it and starting the rotation on first use.
+func Keys() *KeySet {
+	onceKeys.Do(func() {
+		ks := &KeySet{}
+		if err := ks.Reload(); err != nil {
+			zap.L().Fatal("auth: load token keys", zap.Error(err))
+		}
+		if config.Get().TokenKeysDir != "" {
+			ks.rotateBackground(config.Get().TokenKeyRotationInterval)
+		}
+		config.Subscribe(ks.reloadConfig)
+		keys = ks
+	})
+	return keys
+}
+
+// Reload re-reads the keys. On error the current keys stay in use.
+func (ks *KeySet) Reload() error {
+	dir := config.Get().TokenKeysDir
+	if dir == "" {
+		hmacKey := &Key{
+			Method:  jwt.SigningMethodHS256,
+			private: []byte(config.Get().SecretToken),
+			public:  []byte(config.Get().SecretToken),
+		}
+		ks.set(hmacKey, map[string]*Key{"": hmacKey})
+		return nil
+	}
+
+	loaded, err := loadKeys(dir)
+	if err != nil {
+		return err
+	}
+	if len(loaded) == 0 {
+		return fmt.Errorf("%w: %s is empty", ErrNoSigningKey, dir)
+	}
+
+	signingID := config.Get().TokenSigningKeyID
+	if signingID == "" {
+		ids := make([]string, 0, len(loaded))
+		for id := range loaded {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		signingID = ids[len(ids)-1]
+	}
+	signing, ok := loaded[signingID]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKeyID, signingID)
+	}
+
+	ks.set(signing, loaded)
+	return nil
+}
+
+// reloadConfig re-reads the keys on every config reload, the key files may
+// have changed even if the settings didn't.
+func (ks *KeySet) reloadConfig(_, _ *config.Config) {
+	if err := ks.Reload(); err != nil {
+		zap.L().Warn("auth: reload token keys", zap.Error(err))
+	}
+}
+
+func (ks *KeySet) set(signing *Key, keys map[string]*Key) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.signing != nil && ks.signing.ID != signing.ID {
+		zap.L().Info("auth: signing key rotated", zap.String("kid", signing.ID))
+	}
+	ks.signing = signing
+	ks.keys = keys
+}
+
+func (ks *KeySet) Signing() (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if ks.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return ks.signing, nil
+}
+
+func (ks *KeySet) Lookup(kid string) (*Key, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	return key, nil
+}
+
+// Public returns the asymmetric keys that can be published.
+func (ks *KeySet) Public() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	res := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		if key.ID != "" {
+			res = append(res, key)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+func (ks *KeySet) rotateBackground(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ks.Reload(); err != nil {
+					zap.L().Warn("auth: reload token keys", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	shutdowner.Get().AddCloser(func(_ context.Context) error {
+		cancel()
+		return nil
+	})
+}
+
+func loadKeys(dir string) (map[string]*Key, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*Key, len(files))
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		res[id] = key
+	}
+	return res, nil
+}
+
+func parseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: not a PEM file", ErrUnsupportedKey)
+	}
+
+	var (
+		private interface{}
+		err     error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, private)
+	}
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+const (
+	rsaKeyID     = "2022-08-01"
+	ed25519KeyID = "2022-09-01"
+)
+
+// testKeys are generated once, RSA generation is slow.
+var testKeys struct {
+	rsa     *rsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func init() {
+	var err error
+	if testKeys.rsa, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+	if _, testKeys.ed25519, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		panic(err)
+	}
+}
+
+// writeKeys stores the test keys as an RSA PKCS#1 and an Ed25519 PKCS#8 file.
+func writeKeys(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(testKeys.ed25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]*pem.Block{
+		rsaKeyID + ".pem":     {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(testKeys.rsa)},
+		ed25519KeyID + ".pem": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	}
+	for name, block := range files {
+		if err = os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// useKeys loads the key set from dir and makes it the application key set.
+func useKeys(t *testing.T, dir, signingID string) *KeySet {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.SecretToken = "0123456789abcdef0123456789abcdef"
+	cfg.TokenKeysDir = dir
+	cfg.TokenSigningKeyID = signingID
+	config.Set(cfg)
+
+	ks := &KeySet{}
+	if err := ks.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	onceKeys.Do(func() {})
+	keys = ks
+	return ks
+}
+
+func TestReloadSigningKey(t *testing.T) {
+	dir := writeKeys(t)
+	tests := []struct {
+		name      string
+		signingID string
+		want      string
+	}{
+		{"lexically last", "", ed25519KeyID},
+		{"pinned", rsaKeyID, rsaKeyID},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := useKeys(t, dir, tt.signingID).Signing()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.ID != tt.want {
+				t.Fatalf("signing key %q, want %q", key.ID, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown pinned", func(t *testing.T) {
+		ks := useKeys(t, dir, "")
+		cfg := *config.Get()
+		cfg.TokenSigningKeyID = "2022-10-01"
+		config.Set(&cfg)
+		if err := ks.Reload(); !errors.Is(err, ErrUnknownKeyID) {
+			t.Fatalf("Reload = %v, want %v", err, ErrUnknownKeyID)
+		}
+		// the current keys stay in use
+		if key, err := ks.Signing(); err != nil || key.ID != ed25519KeyID {
+			t.Fatalf("signing key after failed reload = %+v, %v", key, err)
+		}
+	})
+
+	t.Run("hmac", func(t *testing.T) {
+		ks := useKeys(t, "", "")
+		key, err := ks.Signing()
+		if err != nil || key.ID != "" || key.Method != jwt.SigningMethodHS256 {
+			t.Fatalf("signing key = %+v, %v", key, err)
+		}
+		if public := ks.Public(); len(public) != 0 {
+			t.Fatalf("HMAC key published: %+v", public)
+		}
+	})
+}
+
+func TestParseToken(t *testing.T) {
+	dir := writeKeys(t)
+	for _, signingID := range []string{rsaKeyID, ed25519KeyID, ""} {
+		keysDir := dir
+		if signingID == "" {
+			keysDir = ""
+		}
+		useKeys(t, keysDir, signingID)
+
+		signed, err := GenerateToken(models.User{ID: "user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := ParseToken(signed)
+		if err != nil {
+			t.Fatalf("%q: ParseToken = %v", signingID, err)
+		}
+		if claims.UID != "user" || claims.JTI == "" || !claims.ExpiresAt.After(time.Now()) {
+			t.Fatalf("%q: claims = %+v", signingID, claims)
+		}
+	}
+}
+
+func TestParseTokenRejected(t *testing.T) {
+	useKeys(t, writeKeys(t), "")
+	publicPEM, err := x509.MarshalPKIXPublicKey(&testKeys.rsa.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"uid": "user", "jti": "jti", "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"HS256 signed with the RSA public key", sign(jwt.SigningMethodHS256, rsaKeyID, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicPEM})), models.ErrInvalidBearerToken},
+		{"RS256 with the Ed25519 kid", sign(jwt.SigningMethodRS256, ed25519KeyID, testKeys.rsa), models.ErrInvalidBearerToken},
+		{"none", sign(jwt.SigningMethodNone, rsaKeyID, jwt.UnsafeAllowNoneSignatureType), models.ErrInvalidBearerToken},
+		{"unknown kid", sign(jwt.SigningMethodEdDSA, "2022-10-01", testKeys.ed25519), ErrUnknownKeyID},
+		{"no kid", sign(jwt.SigningMethodEdDSA, "", testKeys.ed25519), ErrUnknownKeyID},
+	}
+	for _, tt := range tests {
+		if _, err := ParseToken(tt.token); !errors.Is(err, tt.want) {
+			t.Errorf("%s: ParseToken = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	jwks := useKeys(t, writeKeys(t), "").JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS = %+v, want both keys", jwks)
+	}
+
+	rsaJWK, edJWK := jwks.Keys[0], jwks.Keys[1]
+	wantRSA := JWK{
+		Kty: "RSA",
+		Kid: rsaKeyID,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(testKeys.rsa.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testKeys.rsa.E)).Bytes()),
+	}
+	if rsaJWK != wantRSA {
+		t.Fatalf("RSA JWK = %+v, want %+v", rsaJWK, wantRSA)
+	}
+	if rsaJWK.E != "AQAB" {
+		t.Fatalf("RSA exponent %q, want AQAB", rsaJWK.E)
+	}
+
+	wantEd := JWK{
+		Kty: "OKP",
+		Kid: ed25519KeyID,
+		Use: "sig",
+		Alg: "EdDSA",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(testKeys.ed25519.Public().(ed25519.PublicKey)),
+	}
+	if edJWK != wantEd {
+		t.Fatalf("Ed25519 JWK = %+v, want %+v", edJWK, wantEd)
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"go.uber.org/zap"
@@ -10,28 +15,155 @@ import (
 
 var once sync.Once
 
-type config struct {
+// Config is the service configuration. Settings tagged with reload are
+// applied live by Reload, the others need a restart.
+type Config struct {
 	RunAddress     string `env:"RUN_ADDRESS" envDefault:":8080"`
-	AccrualAddress string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	DatabaseURI    string `env:"DATABASE_URI"`
-	SecretToken    string `env:"TOKEN_SIGN_KEY" envDefault:"The Little Man Who Wasn't There"`
+	AdminAddress   string `env:"ADMIN_ADDRESS" envDefault:":9090"`
+	AccrualAddress string `env:"ACCRUAL_SYSTEM_ADDRESS" reload:"true"`
+	DatabaseURI    string `env:"DATABASE_URI" secret:"true"`
+	StorageType    string `env:"STORAGE_TYPE" envDefault:"postgres"`
+	SecretToken    string `env:"TOKEN_SIGN_KEY" secret:"true" reload:"true"`
+
+	TokenKeysDir             string        `env:"TOKEN_KEYS_DIR" reload:"true"`
+	TokenSigningKeyID        string        `env:"TOKEN_SIGNING_KEY_ID" reload:"true"`
+	TokenKeyRotationInterval time.Duration `env:"TOKEN_KEY_ROTATION_INTERVAL" envDefault:"1h"`
+	AccessTokenTTL           time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL          time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"720h"`
+
+	AccrualPollWorkers  int           `env:"ACCRUAL_POLL_WORKERS" envDefault:"4" reload:"true"`
+	AccrualPollInterval time.Duration `env:"ACCRUAL_POLL_INTERVAL" envDefault:"10s" reload:"true"`
+	AccrualTimeout      time.Duration `env:"ACCRUAL_TIMEOUT" envDefault:"1m"`
+
+	DatabaseQueryTimeout time.Duration `env:"DATABASE_QUERY_TIMEOUT" envDefault:"1s"`
+
+	CompressionLevel int `env:"COMPRESSION_LEVEL" envDefault:"5"`
+
+	LoginMinLength     int    `env:"LOGIN_MIN_LENGTH" envDefault:"3"`
+	LoginMaxLength     int    `env:"LOGIN_MAX_LENGTH" envDefault:"64"`
+	LoginPattern       string `env:"LOGIN_PATTERN" envDefault:"^[A-Za-z0-9._@-]+$"`
+	PasswordMinLength  int    `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordMinClasses int    `env:"PASSWORD_MIN_CLASSES" envDefault:"2"`
+
+	LoginMaxFailures   int           `env:"LOGIN_MAX_FAILURES" envDefault:"5" reload:"true"`
+	IPMaxFailures      int           `env:"LOGIN_IP_MAX_FAILURES" envDefault:"20" reload:"true"`
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m" reload:"true"`
+	LoginLockoutBase   time.Duration `env:"LOGIN_LOCKOUT_BASE" envDefault:"1m" reload:"true"`
+	LoginLockoutMax    time.Duration `env:"LOGIN_LOCKOUT_MAX" envDefault:"1h" reload:"true"`
+
+	IdempotencyKeyTTL          time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
+	IdempotencyLeaseTTL        time.Duration `env:"IDEMPOTENCY_LEASE_TTL" envDefault:"1m"`
+	IdempotencyCleanupInterval time.Duration `env:"IDEMPOTENCY_CLEANUP_INTERVAL" envDefault:"1h"`
+
+	OrderEventsHeartbeat time.Duration `env:"ORDER_EVENTS_HEARTBEAT" envDefault:"15s"`
+	OrderEventsRetention time.Duration `env:"ORDER_EVENTS_RETENTION" envDefault:"168h"`
+
+	WebhookTimeout           time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`
+	WebhookMaxAttempts       int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`
+	WebhookRetryBase         time.Duration `env:"WEBHOOK_RETRY_BASE" envDefault:"10s"`
+	WebhookRetryMax          time.Duration `env:"WEBHOOK_RETRY_MAX" envDefault:"1h"`
+	WebhookDispatchInterval  time.Duration `env:"WEBHOOK_DISPATCH_INTERVAL" envDefault:"5s"`
+	WebhookDeliveryRetention time.Duration `env:"WEBHOOK_DELIVERY_RETENTION" envDefault:"720h"`
+
+	TracingExporter     string  `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+	TracingOTLPInsecure bool    `env:"TRACING_OTLP_INSECURE" envDefault:"false"`
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"5s"`
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"3s"`
+
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info" reload:"true"`
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json"`
 }
 
-var instance *config
+// instance holds the current *Config, replaced by Reload.
+var instance atomic.Value
+
+// flagEnv maps the command line flags to the variables they override.
+var flagEnv = []struct {
+	name, env, usage string
+}{
+	{"a", "RUN_ADDRESS", "server address to listen on"},
+	{"m", "ADMIN_ADDRESS", "admin address to serve metrics on, empty to disable"},
+	{"r", "ACCRUAL_SYSTEM_ADDRESS", "accrual system address"},
+	{"d", "DATABASE_URI", "database connection URI"},
+	{"s", "STORAGE_TYPE", "storage type: postgres or memory"},
+	{"w", "ACCRUAL_POLL_WORKERS", "number of workers polling the accrual system"},
+}
+
+// read loads the configuration, later sources overriding earlier ones:
+// defaults, the config file, environment variables and command line flags.
+// A secret may also be read from the file named by its variable with the
+// _FILE suffix, which overrides all of them. args are the command line arguments without the program name.
+func read(args []string) (*Config, bool, error) {
+	fs := flag.NewFlagSet("gophermart", flag.ContinueOnError)
+	configFile := fs.String("c", os.Getenv(configFileEnv), "config file, YAML or TOML (env "+configFileEnv+")")
+	printConfig := fs.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	values := make(map[string]*string, len(flagEnv))
+	for _, f := range flagEnv {
+		values[f.name] = fs.String(f.name, envDefault(f.env), f.usage+" (env "+f.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	environment := make(map[string]string)
+	if *configFile != "" {
+		fileEnv, err := readFile(*configFile)
+		if err != nil {
+			return nil, false, err
+		}
+		for k, v := range fileEnv {
+			environment[k] = v
+		}
+	}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			environment[k] = v
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, fe := range flagEnv {
+			if fe.name == f.Name {
+				environment[fe.env] = *values[f.Name]
+			}
+		}
+	})
+	if err := readSecretFiles(environment); err != nil {
+		return nil, false, err
+	}
+
+	var cfg Config
+	if err := env.Parse(&cfg, env.Options{Environment: environment}); err != nil {
+		return nil, false, err
+	}
+	return &cfg, *printConfig, nil
+}
 
 func load() func() {
 	return func() {
-		var cfg config
-		if err := env.Parse(&cfg); err != nil {
-			zap.L().Fatal("load config: parse flags", zap.Error(err))
+		cfg, printConfig, err := read(os.Args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if err != nil {
+			zap.L().Fatal("load config", zap.Error(err))
 		}
 
-		flag.StringVar(&cfg.RunAddress, "a", cfg.RunAddress, `server address to listen on`)
-		flag.StringVar(&cfg.AccrualAddress, "r", cfg.AccrualAddress, `accrual system address`)
-		flag.StringVar(&cfg.DatabaseURI, "d", cfg.DatabaseURI, `file location to store data in`)
-		flag.Parse()
+		if printConfig {
+			if err = cfg.Print(os.Stdout); err != nil {
+				zap.L().Fatal("print config", zap.Error(err))
+			}
+		}
+		if err = cfg.Validate(); err != nil {
+			zap.L().Fatal("invalid config", zap.Error(err))
+		}
+		if printConfig {
+			os.Exit(0)
+		}
 
-		instance = &cfg
+		instance.Store(cfg)
 	}
 }
 
@@ -39,9 +171,26 @@ func New() {
 	once.Do(load())
 }
 
-func Get() *config {
-	if instance == nil {
-		New()
+func Get() *Config {
+	if cfg, ok := instance.Load().(*Config); ok {
+		return cfg
+	}
+	New()
+	return instance.Load().(*Config)
+}
+
+// Set replaces the configuration without reading the command line and the
+// environment. It is meant for tests of the packages using Get.
+func Set(cfg *Config) {
+	once.Do(func() {})
+	instance.Store(cfg)
+}
+
+// Defaults returns the configuration with the default values only.
+func Defaults() *Config {
+	var cfg Config
+	if err := env.Parse(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		zap.L().Fatal("parse config defaults", zap.Error(err))
 	}
-	return instance
+	return &cfg
 }
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes a file to a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clearEnv unsets the variables for the test, so the environment of the
+// test process doesn't leak into the config.
+func clearEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestReadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "accrual_poll_workers: 5\ndatabase_uri: postgres://file\n")
+	secret := writeFile(t, "database_uri", "postgres://secret\n")
+
+	tests := []struct {
+		name        string
+		file        bool
+		env         map[string]string
+		args        []string
+		wantWorkers int
+		wantURI     string
+	}{
+		{
+			name:        "defaults",
+			wantWorkers: 4,
+		},
+		{
+			name:        "file over defaults",
+			file:        true,
+			wantWorkers: 5,
+			wantURI:     "postgres://file",
+		},
+		{
+			name:        "env over file",
+			file:        true,
+			env:         map[string]string{"ACCRUAL_POLL_WORKERS": "6", "DATABASE_URI": "postgres://env"},
+			wantWorkers: 6,
+			wantURI:     "postgres://env",
+		},
+		{
+			name:        "flags over env",
+			file:        true,
+			env:         map[string]string{"ACCRUAL_POLL_WORKERS": "6", "DATABASE_URI": "postgres://env"},
+			args:        []string{"-w", "7", "-d", "postgres://flag"},
+			wantWorkers: 7,
+			wantURI:     "postgres://flag",
+		},
+		{
+			name:        "secret file over flags",
+			file:        true,
+			env:         map[string]string{"DATABASE_URI": "postgres://env", "DATABASE_URI_FILE": secret},
+			args:        []string{"-d", "postgres://flag"},
+			wantWorkers: 5,
+			wantURI:     "postgres://secret",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, configFileEnv, "ACCRUAL_POLL_WORKERS", "DATABASE_URI", "DATABASE_URI_FILE")
+			if tt.file {
+				t.Setenv(configFileEnv, file)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, printConfig, err := read(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if printConfig {
+				t.Fatal("print config without the flag")
+			}
+			if cfg.AccrualPollWorkers != tt.wantWorkers || cfg.DatabaseURI != tt.wantURI {
+				t.Fatalf("workers %d, database URI %q, want %d and %q", cfg.AccrualPollWorkers, cfg.DatabaseURI, tt.wantWorkers, tt.wantURI)
+			}
+		})
+	}
+}
+
+func TestReadFlags(t *testing.T) {
+	clearEnv(t, configFileEnv, "RUN_ADDRESS")
+	file := writeFile(t, "config.toml", "run_address = \":7000\"\n")
+
+	cfg, printConfig, err := read([]string{"-c", file, "--print-config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !printConfig || cfg.RunAddress != ":7000" {
+		t.Fatalf("print config %v, run address %q", printConfig, cfg.RunAddress)
+	}
+
+	if _, _, err = read([]string{"-unknown"}); err == nil {
+		t.Fatal("unknown flag accepted")
+	}
+	if _, _, err = read([]string{"-c", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Fatal("missing config file accepted")
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFileEnv    = "CONFIG_FILE"
+	secretFileSuffix = "_FILE"
+)
+
+// field describes a config setting by its environment variable.
+type field struct {
+	env         string
+	envDefault  string
+	secret      bool
+	reload      bool
+	structField reflect.StructField
+}
+
+func fields() []field {
+	t := reflect.TypeOf(Config{})
+	res := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, ok := sf.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		res = append(res, field{
+			env:         name,
+			envDefault:  sf.Tag.Get("envDefault"),
+			secret:      sf.Tag.Get("secret") == "true",
+			reload:      sf.Tag.Get("reload") == "true",
+			structField: sf,
+		})
+	}
+	return res
+}
+
+func envDefault(name string) string {
+	for _, f := range fields() {
+		if f.env == name {
+			return f.envDefault
+		}
+	}
+	return ""
+}
+
+// fileKey is the config file key of the variable: run_address for RUN_ADDRESS.
+func fileKey(env string) string {
+	return strings.ToLower(env)
+}
+
+// readFile returns the settings of a flat YAML or TOML file, chosen by the
+// extension, keyed by their environment variables. Unknown keys are errors,
+// so typos don't pass silently.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		_, err = toml.Decode(string(data), &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	known := make(map[string]string)
+	for _, f := range fields() {
+		known[fileKey(f.env)] = f.env
+		if f.secret {
+			known[fileKey(f.env+secretFileSuffix)] = f.env + secretFileSuffix
+		}
+	}
+
+	res := make(map[string]string, len(raw))
+	var unknown []string
+	for key, value := range raw {
+		name, ok := known[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("config file %s: %s must be a scalar", path, key)
+		case nil:
+			res[name] = ""
+		default:
+			res[name] = fmt.Sprint(value)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("config file %s: unknown keys: %s", path, strings.Join(unknown, ", "))
+	}
+	return res, nil
+}
+
+// readSecretFiles replaces NAME_FILE settings of secrets with NAME set to
+// the content of the file, e.g. a mounted Docker or Kubernetes secret.
+// The file wins over NAME given by any other source.
+func readSecretFiles(environment map[string]string) error {
+	for _, f := range fields() {
+		if !f.secret {
+			continue
+		}
+		path, ok := environment[f.env+secretFileSuffix]
+		if !ok || path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s%s: %w", f.env, secretFileSuffix, err)
+		}
+		environment[f.env] = strings.TrimRight(string(data), "\r\n")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			file:    "config.yaml",
+			content: "run_address: \":7000\"\naccrual_poll_workers: 8\ntoken_sign_key_file: /run/secrets/key\nadmin_address: null\n",
+			want:    map[string]string{"RUN_ADDRESS": ":7000", "ACCRUAL_POLL_WORKERS": "8", "TOKEN_SIGN_KEY_FILE": "/run/secrets/key", "ADMIN_ADDRESS": ""},
+		},
+		{
+			name:    "toml",
+			file:    "config.toml",
+			content: "run_address = \":7000\"\naccrual_poll_interval = \"30s\"\ntracing_otlp_insecure = true\n",
+			want:    map[string]string{"RUN_ADDRESS": ":7000", "ACCRUAL_POLL_INTERVAL": "30s", "TRACING_OTLP_INSECURE": "true"},
+		},
+		{
+			name:    "unknown yaml keys",
+			file:    "config.yml",
+			content: "run_adress: \":7000\"\nworkers: 8\n",
+			wantErr: "unknown keys: run_adress, workers",
+		},
+		{
+			name:    "unknown toml keys",
+			file:    "config.toml",
+			content: "run_adress = \":7000\"\nrun_address_file = \"/path\"\n",
+			wantErr: "unknown keys: run_address_file, run_adress",
+		},
+		{
+			name:    "nested value",
+			file:    "config.yaml",
+			content: "run_address:\n  host: localhost\n",
+			wantErr: "run_address must be a scalar",
+		},
+		{
+			name:    "unsupported format",
+			file:    "config.json",
+			content: "{}",
+			wantErr: `unsupported format ".json"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFile(writeFile(t, tt.file, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readFile error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("readFile = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("readFile = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSecretFiles(t *testing.T) {
+	path := writeFile(t, "key", "0123456789\r\n")
+
+	environment := map[string]string{"TOKEN_SIGN_KEY": "plain", "TOKEN_SIGN_KEY_FILE": path, "DATABASE_URI": "postgres://plain"}
+	if err := readSecretFiles(environment); err != nil {
+		t.Fatal(err)
+	}
+	if environment["TOKEN_SIGN_KEY"] != "0123456789" {
+		t.Fatalf("TOKEN_SIGN_KEY = %q, want the file content", environment["TOKEN_SIGN_KEY"])
+	}
+	if environment["DATABASE_URI"] != "postgres://plain" {
+		t.Fatalf("DATABASE_URI = %q without a secret file", environment["DATABASE_URI"])
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	err := readSecretFiles(map[string]string{"TOKEN_SIGN_KEY_FILE": missing})
+	if !os.IsNotExist(errors.Unwrap(err)) || !strings.Contains(err.Error(), "TOKEN_SIGN_KEY_FILE") {
+		t.Fatalf("missing secret file: %v", err)
+	}
+}
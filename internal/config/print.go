@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Print writes the config as a YAML config file, secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range fields() {
+		var value interface{} = v.FieldByIndex(f.structField.Index).Interface()
+		switch typed := value.(type) {
+		case time.Duration:
+			value = typed.String()
+		case string:
+			if f.secret && typed != "" {
+				value = redacted
+			}
+		}
+
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return fmt.Errorf("encode %s: %w", f.env, err)
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: fileKey(f.env)}, &node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+	return enc.Encode(doc)
+}
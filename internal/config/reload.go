@@ -0,0 +1,102 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+var (
+	reloadMu         sync.Mutex
+	subscribers      []subscriber
+	lastSubscriberID uint64
+)
+
+type subscriber struct {
+	id uint64
+	fn func(prev, cur *Config)
+}
+
+// Subscribe calls fn after every successful Reload with the previous and the
+// new config. fn runs in the reloading goroutine and must not block.
+// The returned function cancels the subscription.
+func Subscribe(fn func(prev, cur *Config)) (unsubscribe func()) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	lastSubscriberID++
+	id := lastSubscriberID
+	subscribers = append(subscribers, subscriber{id: id, fn: fn})
+
+	return func() {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		for i, s := range subscribers {
+			if s.id == id {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload re-reads the configuration from the same sources as at startup.
+// Changed settings that can't be applied live keep their current values
+// with a warning. On error the current config stays in use.
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	prev := Get()
+	cur, _, err := read(os.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	var changed, ignored []string
+	prevValue, curValue := reflect.ValueOf(prev).Elem(), reflect.ValueOf(cur).Elem()
+	for _, f := range fields() {
+		prevField := prevValue.FieldByIndex(f.structField.Index)
+		curField := curValue.FieldByIndex(f.structField.Index)
+		if reflect.DeepEqual(prevField.Interface(), curField.Interface()) {
+			continue
+		}
+		if !f.reload {
+			curField.Set(prevField)
+			ignored = append(ignored, f.env)
+			continue
+		}
+		changed = append(changed, f.env)
+	}
+	if err = cur.Validate(); err != nil {
+		return err
+	}
+
+	if len(ignored) > 0 {
+		zap.L().Warn("config: changed settings need a restart, ignored", zap.Strings("settings", ignored))
+	}
+	zap.L().Info("config: reloaded", zap.Strings("changed", changed))
+
+	instance.Store(cur)
+	for _, s := range subscribers {
+		s.fn(prev, cur)
+	}
+	return nil
+}
+
+// ReloadOnSignal reloads the configuration on SIGHUP.
+func ReloadOnSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+
+	go func() {
+		for range ch {
+			if err := Reload(); err != nil {
+				zap.L().Warn("config: reload", zap.Error(err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupReload loads the config from the environment as at startup and
+// returns the observed logs of the following reloads.
+func setupReload(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	clearEnv(t, configFileEnv, "RUN_ADDRESS", "LOG_LEVEL", "ACCRUAL_POLL_WORKERS")
+	t.Setenv("ACCRUAL_SYSTEM_ADDRESS", "http://localhost:8081")
+	t.Setenv("DATABASE_URI", "postgres://localhost/gophermart")
+	t.Setenv("TOKEN_SIGN_KEY", strings.Repeat("k", secretTokenMinLength))
+
+	args := os.Args
+	os.Args = []string{"gophermart"}
+	t.Cleanup(func() { os.Args = args })
+
+	cfg, _, err := read(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Set(cfg)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+	return logs
+}
+
+// subscribe records the configs passed to the subscriber.
+func subscribe(t *testing.T) *[][2]*Config {
+	var calls [][2]*Config
+	t.Cleanup(Subscribe(func(prev, cur *Config) {
+		calls = append(calls, [2]*Config{prev, cur})
+	}))
+	return &calls
+}
+
+func TestReload(t *testing.T) {
+	logs := setupReload(t)
+	calls := subscribe(t)
+	prev := Get()
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("ACCRUAL_POLL_WORKERS", "8")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := Get()
+	if cur == prev || cur.LogLevel != "debug" || cur.AccrualPollWorkers != 8 {
+		t.Fatalf("reloaded config: log level %q, workers %d", cur.LogLevel, cur.AccrualPollWorkers)
+	}
+	if len(*calls) != 1 || (*calls)[0][0] != prev || (*calls)[0][1] != cur {
+		t.Fatalf("subscriber calls = %v, want one with the previous and the new config", *calls)
+	}
+	if logs.FilterMessage("config: changed settings need a restart, ignored").Len() != 0 {
+		t.Fatal("reloadable settings reported as ignored")
+	}
+}
+
+func TestReloadRevertsRestartSettings(t *testing.T) {
+	logs := setupReload(t)
+	calls := subscribe(t)
+	prev := Get()
+
+	t.Setenv("RUN_ADDRESS", ":7000")
+	t.Setenv("LOG_LEVEL", "warn")
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	cur := Get()
+	if cur.RunAddress != prev.RunAddress || cur.LogLevel != "warn" {
+		t.Fatalf("reloaded config: run address %q, log level %q", cur.RunAddress, cur.LogLevel)
+	}
+	if len(*calls) != 1 || (*calls)[0][1].RunAddress != prev.RunAddress {
+		t.Fatalf("subscriber calls = %v", *calls)
+	}
+
+	warnings := logs.FilterMessage("config: changed settings need a restart, ignored").All()
+	if len(warnings) != 1 || warnings[0].Level != zapcore.WarnLevel {
+		t.Fatalf("warnings = %v, want one about RUN_ADDRESS", warnings)
+	}
+	settings, _ := warnings[0].ContextMap()["settings"].([]interface{})
+	if len(settings) != 1 || settings[0] != "RUN_ADDRESS" {
+		t.Fatalf("ignored settings = %v, want [RUN_ADDRESS]", warnings[0].ContextMap()["settings"])
+	}
+}
+
+func TestReloadInvalid(t *testing.T) {
+	setupReload(t)
+	calls := subscribe(t)
+	prev := Get()
+
+	t.Setenv("ACCRUAL_POLL_WORKERS", "0")
+	t.Setenv("LOG_LEVEL", "debug")
+	if err := Reload(); err == nil || !strings.Contains(err.Error(), "ACCRUAL_POLL_WORKERS") {
+		t.Fatalf("Reload = %v, want a validation error", err)
+	}
+	if Get() != prev {
+		t.Fatal("invalid config replaced the current one")
+	}
+	if len(*calls) != 0 {
+		t.Fatalf("subscriber called on a failed reload: %v", *calls)
+	}
+
+	t.Setenv("ACCRUAL_POLL_WORKERS", "many")
+	if err := Reload(); err == nil {
+		t.Fatal("unparsable value reloaded")
+	}
+	if Get() != prev || len(*calls) != 0 {
+		t.Fatal("unparsable config applied")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	setupReload(t)
+	var first, second int
+	unsubscribe := Subscribe(func(_, _ *Config) { first++ })
+	t.Cleanup(Subscribe(func(_, _ *Config) { second++ }))
+
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe()
+	unsubscribe()
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if first != 1 || second != 2 {
+		t.Fatalf("subscribers called %d and %d times, want 1 and 2", first, second)
+	}
+}
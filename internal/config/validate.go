@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"go.uber.org/zap/zapcore"
+)
+
+// secretTokenMinLength is the HS256 key size recommended by RFC 7518.
+const secretTokenMinLength = 32
+
+var (
+	positive = validation.By(func(value interface{}) error {
+		if d, _ := value.(time.Duration); d <= 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	})
+	nonNegative = validation.By(func(value interface{}) error {
+		if d, _ := value.(time.Duration); d < 0 {
+			return errors.New("must not be negative")
+		}
+		return nil
+	})
+	// atLeast is validation.Min for ints that also rejects zero, which Min
+	// treats as an empty value and skips.
+	atLeast = func(min int) validation.Rule {
+		return validation.By(func(value interface{}) error {
+			if n, _ := value.(int); n < min {
+				return fmt.Errorf("must be no less than %d", min)
+			}
+			return nil
+		})
+	}
+	logLevel = validation.By(func(value interface{}) error {
+		var level zapcore.Level
+		return level.UnmarshalText([]byte(value.(string)))
+	})
+)
+
+// Validate checks the settings, so the service fails at startup rather
+// than on first use. The errors are keyed by the environment variables.
+func (c *Config) Validate() error {
+	err := validation.ValidateStruct(c,
+		validation.Field(&c.RunAddress, validation.Required),
+		validation.Field(&c.AccrualAddress, validation.Required, is.RequestURL),
+		validation.Field(&c.StorageType, validation.Required, validation.In("postgres", "memory")),
+		validation.Field(&c.DatabaseURI, validation.When(c.StorageType == "postgres", validation.Required)),
+		validation.Field(&c.SecretToken, validation.When(c.TokenKeysDir == "",
+			validation.Required, validation.Length(secretTokenMinLength, 0))),
+
+		validation.Field(&c.TokenKeyRotationInterval, positive),
+		validation.Field(&c.AccessTokenTTL, positive),
+		validation.Field(&c.RefreshTokenTTL, positive),
+
+		validation.Field(&c.AccrualPollWorkers, atLeast(1)),
+		validation.Field(&c.AccrualPollInterval, positive),
+		validation.Field(&c.AccrualTimeout, positive),
+		validation.Field(&c.DatabaseQueryTimeout, positive),
+		validation.Field(&c.CompressionLevel, atLeast(1), validation.Max(9)),
+
+		validation.Field(&c.LoginMinLength, atLeast(1)),
+		validation.Field(&c.LoginMaxLength, atLeast(c.LoginMinLength)),
+		validation.Field(&c.PasswordMinLength, atLeast(1)),
+		validation.Field(&c.PasswordMinClasses, atLeast(1), validation.Max(4)),
+
+		validation.Field(&c.LoginMaxFailures, atLeast(1)),
+		validation.Field(&c.IPMaxFailures, atLeast(1)),
+		validation.Field(&c.LoginFailureWindow, positive),
+		validation.Field(&c.LoginLockoutBase, positive),
+		validation.Field(&c.LoginLockoutMax, positive),
+
+		validation.Field(&c.IdempotencyKeyTTL, positive),
+		validation.Field(&c.IdempotencyLeaseTTL, positive),
+		validation.Field(&c.IdempotencyCleanupInterval, positive),
+		validation.Field(&c.OrderEventsHeartbeat, positive),
+		validation.Field(&c.OrderEventsRetention, positive),
+
+		validation.Field(&c.WebhookTimeout, positive),
+		validation.Field(&c.WebhookMaxAttempts, atLeast(1)),
+		validation.Field(&c.WebhookRetryBase, positive),
+		validation.Field(&c.WebhookRetryMax, positive),
+		validation.Field(&c.WebhookDispatchInterval, positive),
+		validation.Field(&c.WebhookDeliveryRetention, positive),
+
+		validation.Field(&c.TracingExporter, validation.In("none", "stdout", "otlp")),
+		validation.Field(&c.TracingSampleRatio, validation.Min(0.0), validation.Max(1.0)),
+
+		validation.Field(&c.ShutdownTimeout, positive),
+		validation.Field(&c.ShutdownDrainDelay, nonNegative, validation.By(c.drainBelowTimeout)),
+
+		validation.Field(&c.LogLevel, logLevel),
+		validation.Field(&c.LogFormat, validation.In("json", "console")),
+	)
+
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, f := range fields() {
+		if fieldErr, ok := errs[f.structField.Name]; ok {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", f.env, fieldErr))
+		}
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// drainBelowTimeout keeps the drain delay and the closers within the
+// 2*SHUTDOWN_TIMEOUT watchdog of the shutdowner.
+func (c *Config) drainBelowTimeout(value interface{}) error {
+	if d, _ := value.(time.Duration); d >= c.ShutdownTimeout {
+		return errors.New("must be less than SHUTDOWN_TIMEOUT")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns the defaults completed with the required settings.
+func validConfig() *Config {
+	cfg := Defaults()
+	cfg.AccrualAddress = "http://localhost:8081"
+	cfg.DatabaseURI = "postgres://localhost/gophermart"
+	cfg.SecretToken = strings.Repeat("k", secretTokenMinLength)
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{
+			name:   "valid",
+			modify: func(cfg *Config) {},
+		},
+		{
+			name: "memory storage needs no database",
+			modify: func(cfg *Config) {
+				cfg.StorageType, cfg.DatabaseURI = "memory", ""
+			},
+		},
+		{
+			name: "key directory needs no sign key",
+			modify: func(cfg *Config) {
+				cfg.TokenKeysDir, cfg.SecretToken = "/keys", ""
+			},
+		},
+		{
+			name: "missing accrual address and database",
+			modify: func(cfg *Config) {
+				cfg.AccrualAddress, cfg.DatabaseURI = "", ""
+			},
+			want: "ACCRUAL_SYSTEM_ADDRESS: cannot be blank; DATABASE_URI: cannot be blank",
+		},
+		{
+			name: "short sign key",
+			modify: func(cfg *Config) {
+				cfg.SecretToken = "secret"
+			},
+			want: "TOKEN_SIGN_KEY: the length must be no less than 32",
+		},
+		{
+			name: "bad values",
+			modify: func(cfg *Config) {
+				cfg.AccrualAddress = "not a url"
+				cfg.StorageType = "mysql"
+				cfg.AccrualPollWorkers = 0
+				cfg.AccrualPollInterval = 0
+				cfg.CompressionLevel = 10
+				cfg.LogLevel = "loud"
+			},
+			want: "ACCRUAL_POLL_INTERVAL: must be positive; " +
+				"ACCRUAL_POLL_WORKERS: must be no less than 1; " +
+				"ACCRUAL_SYSTEM_ADDRESS: must be a valid request URL; " +
+				"COMPRESSION_LEVEL: must be no greater than 9; " +
+				`LOG_LEVEL: unrecognized level: "loud"; ` +
+				"STORAGE_TYPE: must be a valid value",
+		},
+		{
+			name: "zero counts",
+			modify: func(cfg *Config) {
+				cfg.CompressionLevel = 0
+				cfg.WebhookMaxAttempts = 0
+			},
+			want: "COMPRESSION_LEVEL: must be no less than 1; WEBHOOK_MAX_ATTEMPTS: must be no less than 1",
+		},
+		{
+			name: "drain delay not below the shutdown timeout",
+			modify: func(cfg *Config) {
+				cfg.ShutdownDrainDelay = cfg.ShutdownTimeout
+			},
+			want: "SHUTDOWN_DRAIN_DELAY: must be less than SHUTDOWN_TIMEOUT",
+		},
+		{
+			name: "negative drain delay",
+			modify: func(cfg *Config) {
+				cfg.ShutdownDrainDelay = -time.Second
+			},
+			want: "SHUTDOWN_DRAIN_DELAY: must not be negative",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
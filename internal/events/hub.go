@@ -0,0 +1,116 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind before
+// it is dropped. Dropped clients reconnect and catch up from the event log.
+const subscriptionBuffer = 64
+
+var (
+	onceInstance sync.Once
+	instance     *Hub
+)
+
+// Hub is an in-process pub/sub of order events with per-user fan-out.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+// Subscription receives the events of one user until it is closed, dropped
+// as too slow or the hub is closed.
+type Subscription struct {
+	uid    string
+	events chan models.OrderEvent
+	hub    *Hub
+}
+
+func New() {
+	onceInstance.Do(func() {
+		instance = &Hub{subs: make(map[string]map[*Subscription]struct{})}
+	})
+}
+
+func Get() *Hub {
+	New()
+	return instance
+}
+
+// Subscribe starts receiving the user events. After Close the returned
+// subscription is already closed.
+func (h *Hub) Subscribe(UID string) *Subscription {
+	sub := &Subscription{
+		uid:    UID,
+		events: make(chan models.OrderEvent, subscriptionBuffer),
+		hub:    h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub
+	}
+	if h.subs[UID] == nil {
+		h.subs[UID] = make(map[*Subscription]struct{})
+	}
+	h.subs[UID][sub] = struct{}{}
+	return sub
+}
+
+// Publish sends the event to the user subscriptions without blocking.
+func (h *Hub) Publish(event models.OrderEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs[event.UID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Close ends all subscriptions, so streams finish before the server shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
+
+// remove must be called with the lock held.
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subs[sub.uid]
+	if !ok {
+		return
+	}
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.uid)
+	}
+	close(sub.events)
+}
+
+// Events is closed when the subscription ends.
+func (s *Subscription) Events() <-chan models.OrderEvent {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func AddWebhook(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		var webhook models.Webhook
+		if err = json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		webhook.UID = user.ID
+
+		if webhook, err = g.AddWebhook(r.Context(), webhook); err != nil {
+			helpers.ValidationError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, webhook)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func ChangePassword(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		var req models.PasswordChange
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		pair, err := g.ChangePassword(r.Context(), user.ID, req)
+		if err != nil {
+			helpers.ValidationError(w, err)
+			return
+		}
+		writeTokenPair(w, pair)
+	}
+}
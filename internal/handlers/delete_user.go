@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func DeleteUser(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		if err = g.DeleteUser(r.Context(), user.ID); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
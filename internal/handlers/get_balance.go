@@ -17,7 +17,7 @@ func GetBalance(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
-		balance, err := g.Storage.GetBalanceByUID(r.Context(), user.ID)
+		balance, err := g.GetBalance(r.Context(), user.ID)
 		if err != nil {
 			helpers.HTTPError(w, err)
 			return
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func GetOrder(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		refresh := r.URL.Query().Get("refresh") == "true"
+		order, err := g.GetOrder(r.Context(), user.ID, chi.URLParam(r, "number"), refresh)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		res, err := json.Marshal(order)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(res)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stsg/gophermart2/internal/helpers"
 	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
 	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
 )
 
@@ -17,7 +18,18 @@ func GetOrders(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
-		orders, err := g.Storage.GetOrdersByUID(r.Context(), user.ID)
+		page, err := parsePage(r.URL.Query(), "uploaded_at")
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		statuses, err := parseStatuses(r.URL.Query())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		orders, next, err := g.ListOrders(r.Context(), user.ID, models.OrderFilter{Page: page, Statuses: statuses})
 		if err != nil {
 			helpers.HTTPError(w, err)
 			return
@@ -29,6 +41,7 @@ func GetOrders(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
+		writeNextPage(w, r, next)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write(res)
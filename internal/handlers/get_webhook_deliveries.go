@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+// GetWebhookDeliveries returns the latest deliveries of the webhook, up to the limit parameter.
+func GetWebhookDeliveries(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		limit := models.DefaultListLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > models.MaxListLimit {
+				helpers.HTTPError(w, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidListParams, models.MaxListLimit))
+				return
+			}
+		}
+
+		deliveries, err := g.ListWebhookDeliveries(r.Context(), user.ID, chi.URLParam(r, "id"), limit)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		if len(deliveries) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, deliveries)
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func GetWebhooks(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		webhooks, err := g.ListWebhooks(r.Context(), user.ID)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		if len(webhooks) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, webhooks)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stsg/gophermart2/internal/helpers"
 	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
 	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
 )
 
@@ -17,7 +18,13 @@ func GetWithdrawals(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
-		withdrawals, err := g.Storage.GetWithdrawalsByUID(r.Context(), user.ID)
+		page, err := parsePage(r.URL.Query(), "processed_at")
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		withdrawals, next, err := g.ListWithdrawals(r.Context(), user.ID, models.WithdrawalFilter{Page: page})
 		if err != nil {
 			helpers.HTTPError(w, err)
 			return
@@ -28,6 +35,7 @@ func GetWithdrawals(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
+		writeNextPage(w, r, next)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write(res)
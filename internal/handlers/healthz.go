@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// Healthz reports that the process is alive and serving requests.
+func Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, models.HealthCheck{Status: models.HealthStatusOK})
+	}
+}
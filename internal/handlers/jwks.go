@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/auth"
+	"github.com/stsg/gophermart2/internal/helpers"
+)
+
+func JWKS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := json.Marshal(auth.Keys().JWKS())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(res)
+	}
+}
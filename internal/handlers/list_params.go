@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+const NextCursorHeader = "X-Next-Cursor"
+
+// parsePage reads the list parameters shared by all lists: limit, cursor,
+// from and to (RFC 3339, to is exclusive) and sort, which is the time field
+// of the list prefixed with "-" for the newest first order. Without limit and
+// cursor the whole list is returned, as it was before the pagination.
+func parsePage(query url.Values, sortField string) (page models.Page, err error) {
+	if query.Get("cursor") != "" {
+		page.Limit = models.DefaultListLimit
+	}
+	if v := query.Get("limit"); v != "" {
+		if page.Limit, err = strconv.Atoi(v); err != nil || page.Limit < 1 || page.Limit > models.MaxListLimit {
+			return page, fmt.Errorf("%w: limit must be between 1 and %d", models.ErrInvalidListParams, models.MaxListLimit)
+		}
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := models.ParseCursor(v)
+		if err != nil {
+			return page, err
+		}
+		page.After = &cursor
+	}
+
+	if page.From, err = parseTimeParam(query, "from"); err != nil {
+		return
+	}
+	if page.Before, err = parseTimeParam(query, "to"); err != nil {
+		return
+	}
+
+	switch query.Get("sort") {
+	case "", sortField:
+	case "-" + sortField:
+		page.Desc = true
+	default:
+		return page, fmt.Errorf("%w: sort must be %s or -%s", models.ErrInvalidListParams, sortField, sortField)
+	}
+	return page, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an RFC 3339 time", models.ErrInvalidListParams, name)
+	}
+	return &t, nil
+}
+
+// parseStatuses reads the status parameter, repeated or comma separated.
+func parseStatuses(query url.Values) (statuses []models.AccrualStatus, err error) {
+	for _, v := range query["status"] {
+		for _, s := range strings.Split(v, ",") {
+			status := models.AccrualStatus(strings.ToUpper(strings.TrimSpace(s)))
+			if !status.IsValid() {
+				return nil, fmt.Errorf("%w: unknown status %q", models.ErrInvalidListParams, s)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return
+}
+
+// writeNextPage points the client at the next page with the Link header
+// and the X-Next-Cursor header, if there is one.
+func writeNextPage(w http.ResponseWriter, r *http.Request, next *models.Cursor) {
+	if next == nil {
+		return
+	}
+	cursor := next.Encode()
+
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+
+	w.Header().Set(NextCursorHeader, cursor)
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, link.String()))
+}
@@ -1,13 +1,10 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
-	"github.com/stsg/gophermart2/internal/auth"
 	"github.com/stsg/gophermart2/internal/helpers"
 	"github.com/stsg/gophermart2/internal/middlewares"
-	"github.com/stsg/gophermart2/internal/models"
 	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
 )
 
@@ -19,22 +16,11 @@ func Login(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
-		dbUser, err := g.Storage.GetUserByLogin(r.Context(), user)
+		pair, err := g.Login(r.Context(), user, r.RemoteAddr)
 		if err != nil {
 			helpers.HTTPError(w, err)
 			return
 		}
-		if !auth.Authenticate(dbUser, user) {
-			helpers.HTTPError(w, models.ErrInvalidLoginAttempt)
-			return
-		}
-
-		token, err := auth.GenerateToken(user)
-		if err != nil {
-			helpers.HTTPError(w, err)
-			return
-		}
-		w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
-		w.WriteHeader(http.StatusOK)
+		writeTokenPair(w, pair)
 	}
 }
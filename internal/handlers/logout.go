@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func Logout(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := middlewares.GetTokenFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		var req models.RefreshTokenRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		if err = g.Logout(r.Context(), claims, req.RefreshToken); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
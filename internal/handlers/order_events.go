@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/logger"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+	"go.uber.org/zap"
+)
+
+// OrderEvents streams the user order events as Server-Sent Events. A client
+// resuming with Last-Event-ID (or the last_event_id parameter, EventSource
+// can't set headers) first gets the logged events it missed.
+func OrderEvents(g *gophermart.Gophermart) http.HandlerFunc {
+	heartbeat := config.Get().OrderEventsHeartbeat
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		lastID, resume, err := parseLastEventID(r)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		// subscribe before reading the log, so no event falls in between
+		sub := g.Events.Subscribe(user.ID)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		for resume {
+			events, err := g.ListOrderEvents(r.Context(), user.ID, lastID)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("order events: read event log", zap.Error(err))
+				return
+			}
+			for _, event := range events {
+				if err = writeOrderEvent(w, event); err != nil {
+					return
+				}
+				lastID = event.ID
+			}
+			resume = len(events) > 0
+		}
+		flusher.Flush()
+		// the log holds every event up to lastID, but the events committed
+		// right after it may still be published in any order
+		replayedID := lastID
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				// already sent from the log
+				if event.ID <= replayedID {
+					continue
+				}
+				if err = writeOrderEvent(w, event); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseLastEventID(r *http.Request) (ID int64, ok bool, err error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, false, nil
+	}
+	if ID, err = strconv.ParseInt(v, 10, 64); err != nil || ID < 0 {
+		return 0, false, models.ErrInvalidLastEventID
+	}
+	return ID, true, nil
+}
+
+func writeOrderEvent(w http.ResponseWriter, event models.OrderEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: order\ndata: %s\n\n", event.ID, data)
+	return err
+}
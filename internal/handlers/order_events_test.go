@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/events"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+)
+
+// readEventID returns the ID of the next event in the stream.
+func readEventID(t *testing.T, r *bufio.Reader) int64 {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := strings.TrimPrefix(line, "id: "); v != line {
+			ID, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ID
+		}
+	}
+}
+
+// TestOrderEventsOutOfOrder checks that live events published out of the ID
+// order, as concurrent transactions commit them, are all streamed.
+func TestOrderEventsOutOfOrder(t *testing.T) {
+	config.Set(config.Defaults())
+	ctx := context.Background()
+	UID := uuid.NewString()
+	g := &gophermart.Gophermart{Storage: memory.New(), Events: events.Get()}
+
+	logged, err := g.Storage.AddOrderEvent(ctx, models.OrderEvent{UID: UID, OrderID: "1", Status: models.AccrualStatusProcessing})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), middlewares.UserCtxName, models.User{ID: UID})
+		OrderEvents(g)(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "0")
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+
+	if ID := readEventID(t, stream); ID != logged.ID {
+		t.Fatalf("replayed event %d, want %d", ID, logged.ID)
+	}
+
+	// the stream is subscribed once it replays the log
+	later := models.OrderEvent{ID: logged.ID + 2, UID: UID, OrderID: "3", Status: models.AccrualStatusProcessing}
+	earlier := models.OrderEvent{ID: logged.ID + 1, UID: UID, OrderID: "2", Status: models.AccrualStatusProcessing}
+	g.Events.Publish(logged)
+	g.Events.Publish(later)
+	g.Events.Publish(earlier)
+
+	for _, want := range []int64{later.ID, earlier.ID} {
+		if ID := readEventID(t, stream); ID != want {
+			t.Fatalf("streamed event %d, want %d", ID, want)
+		}
+	}
+}
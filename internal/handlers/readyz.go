@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+// Readyz reports whether the service can handle requests, with the result
+// of every dependency check. It responds 503 if any of them fails.
+func Readyz(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		readiness := g.CheckReadiness(r.Context())
+		status := http.StatusOK
+		if !readiness.IsReady() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, readiness)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/models"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func RefreshToken(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req models.RefreshTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		pair, err := g.RefreshTokens(r.Context(), req.RefreshToken)
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		writeTokenPair(w, pair)
+	}
+}
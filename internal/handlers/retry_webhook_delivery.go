@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/middlewares"
+	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
+)
+
+func RetryWebhookDelivery(g *gophermart.Gophermart) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := middlewares.GetUserFromCtx(r.Context())
+		if err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+
+		if err = g.RetryWebhookDelivery(r.Context(), user.ID, chi.URLParam(r, "id"), chi.URLParam(r, "deliveryID")); err != nil {
+			helpers.HTTPError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
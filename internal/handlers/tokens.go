@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+func writeTokenPair(w http.ResponseWriter, pair models.TokenPair) {
+	res, err := json.Marshal(pair)
+	if err != nil {
+		helpers.HTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", pair.AccessToken))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
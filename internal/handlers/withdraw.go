@@ -1,13 +1,10 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/stsg/gophermart2/internal/helpers"
-	"github.com/stsg/gophermart2/internal/luhn"
 	"github.com/stsg/gophermart2/internal/middlewares"
 	"github.com/stsg/gophermart2/internal/models"
 	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
@@ -27,28 +24,7 @@ func Withdraw(g *gophermart.Gophermart) http.HandlerFunc {
 			return
 		}
 
-		if !luhn.Valid(withdrawal.OrderID) {
-			helpers.HTTPError(w, models.ErrInvalidOrderNumber)
-			return
-		}
-
-		err = g.Storage.Transaction(r.Context(), func(ctx context.Context, tx *sqlx.Tx) (err error) {
-			balance, err := g.Storage.GetCurrentBalanceByUID(ctx, user.ID, tx)
-			if err != nil {
-				return
-			}
-
-			if balance < withdrawal.Amount {
-				return models.ErrInsufficientFunds
-			}
-
-			if err = g.Storage.AddWithdrawal(ctx, withdrawal, tx); err != nil {
-				return
-			}
-
-			return g.Storage.IncrBalanceWithdrawnByUID(ctx, user.ID, withdrawal.Amount, tx)
-		})
-		if err != nil {
+		if err = g.Withdraw(r.Context(), withdrawal); err != nil {
 			helpers.HTTPError(w, err)
 			return
 		}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		helpers.HTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(res)
+}
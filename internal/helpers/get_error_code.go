@@ -1,26 +1,62 @@
 package helpers
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/stsg/gophermart2/internal/models"
 )
 
 func HTTPError(w http.ResponseWriter, err error) {
+	var retryAfter *models.RetryAfterError
+	if errors.As(err, &retryAfter) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.RetryAfter.Seconds()))))
+	}
 	http.Error(w, err.Error(), GetStatusByError(err))
 }
 
+// ValidationError writes field-level validation errors as a JSON object,
+// any other error is written by HTTPError.
+func ValidationError(w http.ResponseWriter, err error) {
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		HTTPError(w, err)
+		return
+	}
+
+	res, err := json.Marshal(struct {
+		Errors validation.Errors `json:"errors"`
+	}{errs})
+	if err != nil {
+		HTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(res)
+}
+
 func GetStatusByError(err error) int {
 	switch {
 	case errorsAre(err, models.ErrInsufficientFunds):
 		return http.StatusPaymentRequired
-	case errorsAre(err, models.ErrUserAlreadyExists, models.ErrOrderBelongsAnotherUser):
+	case errorsAre(err, models.ErrUserAlreadyExists, models.ErrOrderBelongsAnotherUser, models.ErrWithdrawalAlreadyExists,
+		models.ErrIdempotencyKeyInProgress):
 		return http.StatusConflict
-	case errorsAre(err, models.ErrUserUnauthorized, models.ErrInvalidLoginAttempt, models.ErrInvalidBearerTokenFormat):
+	case errorsAre(err, models.ErrUserUnauthorized, models.ErrInvalidLoginAttempt, models.ErrInvalidPassword, models.ErrInvalidBearerTokenFormat,
+		models.ErrTokenRevoked, models.ErrInvalidRefreshToken, models.ErrRefreshTokenReused):
 		return http.StatusUnauthorized
-	case errorsAre(err, models.ErrInvalidOrderNumber):
+	case errorsAre(err, models.ErrTooManyLoginAttempts):
+		return http.StatusTooManyRequests
+	case errorsAre(err, models.ErrInvalidOrderNumber, models.ErrInvalidAmount, models.ErrIdempotencyKeyReused):
 		return http.StatusUnprocessableEntity
+	case errorsAre(err, models.ErrOrderNotFound, models.ErrWebhookNotFound, models.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
 	case errorsAre(err, models.ErrNoOrders, models.ErrNoWithdrawals):
 		return http.StatusNoContent
 	default:
@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"go.uber.org/zap"
+)
+
+// Storage keeps the keys with their responses, so repeats are recognized
+// after restarts and by every replica.
+type Storage interface {
+	GetIdempotencyKey(ctx context.Context, UID string, key string) (models.IdempotencyKey, error)
+	AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error)
+	SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, UID string, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
+}
+
+// Keys makes requests carrying an Idempotency-Key header safe to retry.
+//
+// The first request with a key reserves it for the user together with the
+// request fingerprint, its response is stored when it is handled. Repeats get
+// the stored response, repeats with another fingerprint are rejected. The
+// reservation is a lease: if the request is never completed nor released,
+// e.g. the process crashed, the key can be taken over once the lease expires.
+// Completed keys expire after the TTL and are removed by the cleanup job.
+type Keys struct {
+	storage         Storage
+	ttl             time.Duration
+	lease           time.Duration
+	cleanupInterval time.Duration
+}
+
+func New(storage Storage) *Keys {
+	cfg := config.Get()
+	return &Keys{
+		storage:         storage,
+		ttl:             cfg.IdempotencyKeyTTL,
+		lease:           cfg.IdempotencyLeaseTTL,
+		cleanupInterval: cfg.IdempotencyCleanupInterval,
+	}
+}
+
+// Begin reserves the key for the request. If the key is already known it
+// returns the stored key and true, so the response can be replayed.
+// models.ErrIdempotencyKeyReused is returned if the fingerprint differs and
+// models.ErrIdempotencyKeyInProgress if the first request is not handled yet.
+func (k *Keys) Begin(ctx context.Context, UID, key, fingerprint string) (res models.IdempotencyKey, replay bool, err error) {
+	res = models.IdempotencyKey{
+		UID:         UID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(k.lease),
+	}
+
+	added, err := k.storage.AddIdempotencyKey(ctx, res)
+	if err != nil || added {
+		return
+	}
+
+	existing, err := k.storage.GetIdempotencyKey(ctx, UID, key)
+	switch {
+	case errors.Is(err, models.ErrIdempotencyKeyNotFound):
+		// released or expired right after the conflict
+		return res, false, models.ErrIdempotencyKeyInProgress
+	case err != nil:
+		return
+	case existing.Fingerprint != fingerprint:
+		return res, false, models.ErrIdempotencyKeyReused
+	case !existing.IsCompleted():
+		return res, false, models.ErrIdempotencyKeyInProgress
+	}
+	return existing, true, nil
+}
+
+// Complete stores the response to replay for the key reserved by Begin and
+// keeps it for the TTL.
+func (k *Keys) Complete(ctx context.Context, key models.IdempotencyKey) error {
+	key.ExpiresAt = time.Now().Add(k.ttl)
+	return k.storage.SaveIdempotencyKeyResponse(ctx, key)
+}
+
+// Release forgets the key, so the request can be retried with it.
+func (k *Keys) Release(ctx context.Context, UID, key string) error {
+	return k.storage.DeleteIdempotencyKey(ctx, UID, key)
+}
+
+// RunCleanup periodically removes expired keys until ctx is done.
+func (k *Keys) RunCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(k.cleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := k.storage.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+					zap.L().Warn("idempotency keys: delete expired", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Fingerprint identifies the request by its method, path and body.
+func Fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
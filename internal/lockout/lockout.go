@@ -0,0 +1,142 @@
+package lockout
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+)
+
+// Storage keeps failure counters, so limits survive restarts and are shared by replicas.
+type Storage interface {
+	GetLoginAttempt(ctx context.Context, key string) (models.LoginAttempt, error)
+	AddLoginFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempt, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+// Lockout throttles login attempts by login and by client IP.
+//
+// Once a key reaches its failure limit within the failure window it is locked
+// for the base duration, doubled on every further failure up to the maximum.
+// The limits follow config reloads.
+type Lockout struct {
+	storage Storage
+
+	mu     sync.RWMutex
+	limits limits
+}
+
+type limits struct {
+	window time.Duration
+	base   time.Duration
+	max    time.Duration
+
+	loginMaxFailures int
+	ipMaxFailures    int
+}
+
+func newLimits(cfg *config.Config) limits {
+	return limits{
+		window:           cfg.LoginFailureWindow,
+		base:             cfg.LoginLockoutBase,
+		max:              cfg.LoginLockoutMax,
+		loginMaxFailures: cfg.LoginMaxFailures,
+		ipMaxFailures:    cfg.IPMaxFailures,
+	}
+}
+
+func New(storage Storage) *Lockout {
+	l := &Lockout{
+		storage: storage,
+		limits:  newLimits(config.Get()),
+	}
+	unsubscribe := config.Subscribe(func(_, cur *config.Config) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.limits = newLimits(cur)
+	})
+	shutdowner.Get().AddCloser(func(context.Context) error {
+		unsubscribe()
+		return nil
+	})
+	return l
+}
+
+func (l *Lockout) currentLimits() limits {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limits
+}
+
+// Check returns models.ErrTooManyLoginAttempts wrapped in *models.RetryAfterError
+// if the login or the address is locked.
+func (l *Lockout) Check(ctx context.Context, login, addr string) error {
+	var lockedFor time.Duration
+	for _, key := range []string{loginKey(login), ipKey(addr)} {
+		attempt, err := l.storage.GetLoginAttempt(ctx, key)
+		if err != nil {
+			return err
+		}
+		if d := attempt.LockedFor(); d > lockedFor {
+			lockedFor = d
+		}
+	}
+
+	if lockedFor > 0 {
+		return &models.RetryAfterError{Err: models.ErrTooManyLoginAttempts, RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// Fail counts a failed attempt for both the login and the address.
+func (l *Lockout) Fail(ctx context.Context, login, addr string) error {
+	lim := l.currentLimits()
+	if err := l.fail(ctx, lim, loginKey(login), lim.loginMaxFailures); err != nil {
+		return err
+	}
+	return l.fail(ctx, lim, ipKey(addr), lim.ipMaxFailures)
+}
+
+// Succeed clears the login counter. The address counter is kept, otherwise
+// a single valid account would let an attacker reset it.
+func (l *Lockout) Succeed(ctx context.Context, login string) error {
+	return l.storage.ResetLoginAttempts(ctx, loginKey(login))
+}
+
+func (l *Lockout) fail(ctx context.Context, lim limits, key string, maxFailures int) error {
+	attempt, err := l.storage.AddLoginFailure(ctx, key, lim.window)
+	if err != nil {
+		return err
+	}
+	if maxFailures <= 0 || attempt.Failures < maxFailures {
+		return nil
+	}
+	return l.storage.LockLogin(ctx, key, time.Now().Add(lim.lockDuration(attempt.Failures-maxFailures)))
+}
+
+func (lim limits) lockDuration(excess int) time.Duration {
+	d := lim.base
+	for i := 0; i < excess && d < lim.max; i++ {
+		d *= 2
+	}
+	if d > lim.max {
+		d = lim.max
+	}
+	return d
+}
+
+func loginKey(login string) string {
+	return "login:" + login
+}
+
+func ipKey(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return "ip:" + addr
+}
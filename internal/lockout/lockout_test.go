@@ -0,0 +1,180 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+)
+
+var testLimits = limits{
+	window:           time.Hour,
+	base:             time.Minute,
+	max:              10 * time.Minute,
+	loginMaxFailures: 3,
+	ipMaxFailures:    5,
+}
+
+func newTestLockout() (*Lockout, storages.Storager) {
+	s := memory.New()
+	return &Lockout{storage: s, limits: testLimits}, s
+}
+
+func TestLockDuration(t *testing.T) {
+	tests := []struct {
+		excess int
+		want   time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{3, 8 * time.Minute},
+		{4, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := testLimits.lockDuration(tt.excess); got != tt.want {
+			t.Errorf("lockDuration(%d) = %v, want %v", tt.excess, got, tt.want)
+		}
+	}
+}
+
+// lockedFor returns how long the key is locked in the storage.
+func lockedFor(t *testing.T, s storages.Storager, key string) time.Duration {
+	t.Helper()
+	attempt, err := s.GetLoginAttempt(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attempt.LockedFor()
+}
+
+func TestFailByLogin(t *testing.T) {
+	l, s := newTestLockout()
+	ctx := context.Background()
+
+	// every failure comes from another address, only the login is counted up to the limit
+	for i := 1; i < testLimits.loginMaxFailures; i++ {
+		if err := l.Fail(ctx, "user", "192.0.2."+strconv.Itoa(i)+":1234"); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Check(ctx, "user", "192.0.2.100:1234"); err != nil {
+			t.Fatalf("locked after %d failures: %v", i, err)
+		}
+	}
+	if err := l.Fail(ctx, "user", "192.0.2.99:1234"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(ctx, "user", "192.0.2.100:1234"); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Fatalf("Check after reaching the login limit = %v", err)
+	}
+	if d := lockedFor(t, s, "login:user"); d <= 0 || d > testLimits.base {
+		t.Fatalf("login locked for %v, want up to %v", d, testLimits.base)
+	}
+	if d := lockedFor(t, s, "ip:192.0.2.99"); d != 0 {
+		t.Fatalf("address locked for %v after a single failure", d)
+	}
+	// another login from the same address is not affected
+	if err := l.Check(ctx, "other", "192.0.2.99:1234"); err != nil {
+		t.Fatalf("Check of another login = %v", err)
+	}
+
+	// further failures double the lock
+	if err := l.Fail(ctx, "user", "192.0.2.99:1234"); err != nil {
+		t.Fatal(err)
+	}
+	if d := lockedFor(t, s, "login:user"); d <= testLimits.base || d > 2*testLimits.base {
+		t.Fatalf("login locked for %v after one more failure, want up to %v", d, 2*testLimits.base)
+	}
+}
+
+func TestFailByAddress(t *testing.T) {
+	l, s := newTestLockout()
+	ctx := context.Background()
+
+	// every failure is for another login, only the address is counted up to the limit
+	for i := 1; i <= testLimits.ipMaxFailures; i++ {
+		if err := l.Fail(ctx, "user"+strconv.Itoa(i), "192.0.2.1:"+strconv.Itoa(1000+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := lockedFor(t, s, "ip:192.0.2.1"); d <= 0 || d > testLimits.base {
+		t.Fatalf("address locked for %v, want up to %v", d, testLimits.base)
+	}
+	for i := 1; i <= testLimits.ipMaxFailures; i++ {
+		if d := lockedFor(t, s, "login:user"+strconv.Itoa(i)); d != 0 {
+			t.Fatalf("login user%d locked for %v after a single failure", i, d)
+		}
+	}
+	if err := l.Check(ctx, "new", "192.0.2.1:2000"); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Fatalf("Check from the locked address = %v", err)
+	}
+	if err := l.Check(ctx, "new", "192.0.2.2:2000"); err != nil {
+		t.Fatalf("Check from another address = %v", err)
+	}
+}
+
+func TestSucceed(t *testing.T) {
+	l, s := newTestLockout()
+	ctx := context.Background()
+
+	for i := 0; i < testLimits.loginMaxFailures-1; i++ {
+		if err := l.Fail(ctx, "user", "192.0.2.1:1234"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Succeed(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	login, err := s.GetLoginAttempt(ctx, "login:user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := s.GetLoginAttempt(ctx, "ip:192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.Failures != 0 || addr.Failures != testLimits.loginMaxFailures-1 {
+		t.Fatalf("after success login failures = %d, address failures = %d", login.Failures, addr.Failures)
+	}
+}
+
+func TestCheckRetryAfter(t *testing.T) {
+	l, s := newTestLockout()
+	ctx := context.Background()
+
+	if _, err := s.AddLoginFailure(ctx, "login:user", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddLoginFailure(ctx, "ip:192.0.2.1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	// the longer of the two locks is reported
+	if err := s.LockLogin(ctx, "login:user", time.Now().Add(90*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LockLogin(ctx, "ip:192.0.2.1", time.Now().Add(30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	err := l.Check(ctx, "user", "192.0.2.1:1234")
+	var retryAfter *models.RetryAfterError
+	if !errors.As(err, &retryAfter) || !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Fatalf("Check = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	helpers.HTTPError(w, err)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "90" {
+		t.Fatalf("response %d with Retry-After %q, want %d and 90", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+}
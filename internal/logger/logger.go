@@ -4,23 +4,104 @@ import (
 	"context"
 	"sync"
 
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stsg/gophermart2/internal/config"
 	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-var once sync.Once
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+var (
+	once  sync.Once
+	level = zap.NewAtomicLevel()
+)
 
+type ctxKey struct{}
+
+// New replaces the global logger with the one configured by config.LogLevel
+// and config.LogFormat. Sensitive fields are redacted, see redactedKeys.
 func New() {
 	once.Do(func() {
-		logger, err := zap.NewProduction()
+		// configuration errors are reported with the default logger
+		if logger, err := zap.NewProduction(); err == nil {
+			zap.ReplaceGlobals(logger)
+		}
+
+		cfg := config.Get()
+		if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+			zap.L().Fatal("logger: parse level", zap.Error(err))
+		}
+
+		zapCfg := zap.NewProductionConfig()
+		zapCfg.Level = level
+		switch cfg.LogFormat {
+		case FormatJSON:
+		case FormatConsole:
+			zapCfg.Encoding = FormatConsole
+			zapCfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+		default:
+			zap.L().Fatal("logger: unknown format", zap.String("format", cfg.LogFormat))
+		}
+
+		logger, err := zapCfg.Build(zap.WrapCore(newRedactCore))
 		if err != nil {
 			zap.L().Fatal(err.Error())
 		}
 		addToShutdowner(logger)
 		zap.ReplaceGlobals(logger)
+		config.Subscribe(reloadLevel)
 	})
 }
 
+func reloadLevel(prev, cur *config.Config) {
+	if prev.LogLevel == cur.LogLevel {
+		return
+	}
+	if err := level.UnmarshalText([]byte(cur.LogLevel)); err != nil {
+		zap.L().Warn("logger: reload level", zap.Error(err))
+	}
+}
+
+// WithContext stores the request-scoped logger in ctx.
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// With returns ctx carrying the request-scoped logger extended with fields.
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	return WithContext(ctx, requestLogger(ctx).With(fields...))
+}
+
+// FromContext returns the request-scoped logger, or the global logger with
+// the request ID, with the trace IDs carried by ctx, so log entries can be
+// correlated with traces.
+func FromContext(ctx context.Context) *zap.Logger {
+	logger := requestLogger(ctx)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With(
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return logger
+}
+
+func requestLogger(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	logger := zap.L()
+	if reqID := middleware.GetReqID(ctx); reqID != "" {
+		logger = logger.With(zap.String("request_id", reqID))
+	}
+	return logger
+}
+
 func addToShutdowner(logger *zap.Logger) {
 	shutdowner.Get().AddCloser(func(ctx context.Context) error {
 		if err := logger.Sync(); err != nil {
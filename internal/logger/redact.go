@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// redactedKeys are field keys and header names whose values are never logged.
+var redactedKeys = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"set-cookie":          {},
+	"password":            {},
+	"old_password":        {},
+	"new_password":        {},
+	"token":               {},
+	"access_token":        {},
+	"refresh_token":       {},
+	"secret":              {},
+}
+
+func isRedacted(key string) bool {
+	_, ok := redactedKeys[strings.ToLower(key)]
+	return ok
+}
+
+// redactCore replaces the values of sensitive fields, so they can't leak
+// through a careless zap.String("password", ...) or zap.Any.
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return redactCore{core}
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var res []zapcore.Field
+	for i, field := range fields {
+		if !isRedacted(field.Key) {
+			continue
+		}
+		if res == nil {
+			res = append(make([]zapcore.Field, 0, len(fields)), fields...)
+		}
+		res[i] = zap.String(field.Key, redacted)
+	}
+	if res == nil {
+		return fields
+	}
+	return res
+}
+
+// Headers logs the HTTP headers with the credentials redacted.
+func Headers(key string, header http.Header) zap.Field {
+	return zap.Object(key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for name, values := range header {
+			if isRedacted(name) {
+				enc.AddString(name, redacted)
+				continue
+			}
+			enc.AddString(name, strings.Join(values, ", "))
+		}
+		return nil
+	}))
+}
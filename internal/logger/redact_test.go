@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger() (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(newRedactCore(core)), logs
+}
+
+func TestRedactFields(t *testing.T) {
+	logger, logs := newObservedLogger()
+	logger.With(zap.String("Password", "s3cr3t"), zap.String("login", "alice")).Info("login",
+		zap.String("authorization", "Bearer abc"),
+		zap.String("token", "abc"),
+		zap.String("refresh_token", "def"),
+		zap.String("cookie", "session=ghi"),
+		zap.Int("new_password", 123456),
+		zap.String("path", "/api/user/login"),
+	)
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("%d entries logged, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	for _, key := range []string{"Password", "authorization", "token", "refresh_token", "cookie", "new_password"} {
+		if fields[key] != redacted {
+			t.Errorf("%s = %v, want it redacted", key, fields[key])
+		}
+	}
+	if fields["login"] != "alice" || fields["path"] != "/api/user/login" {
+		t.Errorf("other fields changed: %v", fields)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	logger, logs := newObservedLogger()
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc")
+	header.Set("Cookie", "session=ghi")
+	header.Add("Accept", "application/json")
+	header.Add("Accept", "text/plain")
+	logger.Debug("request", Headers("headers", header))
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 {
+		t.Fatalf("%d entries logged, want 1", len(entries))
+	}
+	headers, ok := entries[0].ContextMap()["headers"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("headers = %#v", entries[0].ContextMap()["headers"])
+	}
+	if headers["Authorization"] != redacted || headers["Cookie"] != redacted {
+		t.Errorf("credentials logged: %v", headers)
+	}
+	if headers["Accept"] != "application/json, text/plain" {
+		t.Errorf("Accept = %v", headers["Accept"])
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "gophermart"
+
+// Registry holds all application metrics, exposed on the admin address.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	DBTransactionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Storage transaction duration, including retries.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	DBTransactionRollbacks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_rollbacks_total",
+		Help:      "Storage transactions rolled back.",
+	})
+
+	DBTransactionRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "transaction_retries_total",
+		Help:      "Transactions restarted after a deadlock.",
+	})
+
+	AccrualRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "requests_total",
+		Help:      "Accrual system requests by outcome: 200, 204, 429, 5xx, other or error.",
+	}, []string{"outcome"})
+
+	AccrualRequestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "request_duration_seconds",
+		Help:      "Accrual system request latency.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	AccrualUnfinishedOrders = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "unfinished_orders",
+		Help:      "Orders waiting for the final accrual status at the last poll.",
+	})
+
+	AccrualQueuedOrders = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "queued_orders",
+		Help:      "Orders queued or in flight in the accrual poller.",
+	})
+
+	PointsOutstanding = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "points",
+		Name:      "outstanding",
+		Help:      "Sum of the current balances of all users.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		DBTransactionDuration,
+		DBTransactionRollbacks,
+		DBTransactionRetries,
+		AccrualRequests,
+		AccrualRequestDuration,
+		AccrualUnfinishedOrders,
+		AccrualQueuedOrders,
+		PointsOutstanding,
+	)
+}
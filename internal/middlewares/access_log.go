@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stsg/gophermart2/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type accessLogCtxKey struct{}
+
+// accessLogEntry collects the fields known only to inner middlewares.
+type accessLogEntry struct {
+	userID string
+}
+
+// AccessLog logs every request once it is handled and stores the request
+// logger in the context for handlers and storages, see logger.FromContext.
+// Server errors are logged at error level, request headers at debug level
+// with the credentials redacted. It must run after middleware.RequestID.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		entry := &accessLogEntry{}
+
+		ctx := logger.With(r.Context())
+		ctx = context.WithValue(ctx, accessLogCtxKey{}, entry)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		route := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		level := zapcore.InfoLevel
+		if status >= http.StatusInternalServerError {
+			level = zapcore.ErrorLevel
+		}
+		reqLogger := logger.FromContext(ctx)
+		if ce := reqLogger.Check(level, "http request"); ce != nil {
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", route),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", status),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("remote_addr", r.RemoteAddr),
+			}
+			if entry.userID != "" {
+				fields = append(fields, zap.String("user_id", entry.userID))
+			}
+			if reqLogger.Core().Enabled(zapcore.DebugLevel) {
+				fields = append(fields, logger.Headers("headers", r.Header))
+			}
+			ce.Write(fields...)
+		}
+	})
+}
+
+// setAccessLogUser adds the authenticated user to the access log entry and
+// to the request logger passed down with the returned context.
+func setAccessLogUser(ctx context.Context, UID string) context.Context {
+	if entry, ok := ctx.Value(accessLogCtxKey{}).(*accessLogEntry); ok {
+		entry.userID = UID
+	}
+	return logger.With(ctx, zap.String("user_id", UID))
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, AccessLog)
+	r.Group(func(r chi.Router) {
+		// stands in for TokenValidation
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(setAccessLogUser(r.Context(), "user-1")))
+			})
+		})
+		r.Get("/api/user/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/orders/79927398713", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entries := logs.FilterMessage("http request").AllUntimed()
+	if len(entries) != 2 {
+		t.Fatalf("%d access log entries, want 2", len(entries))
+	}
+
+	user := entries[0].ContextMap()
+	if user["user_id"] != "user-1" || user["route"] != "/api/user/orders/{number}" || user["status"] != int64(http.StatusNoContent) ||
+		user["path"] != "/api/user/orders/79927398713" || user["request_id"] == nil || entries[0].Level != zapcore.InfoLevel {
+		t.Fatalf("access log entry %v at %s", user, entries[0].Level)
+	}
+	if headers, _ := user["headers"].(map[string]interface{}); headers["Authorization"] != "[REDACTED]" {
+		t.Fatalf("headers logged as %v", user["headers"])
+	}
+
+	anonymous := entries[1].ContextMap()
+	if _, ok := anonymous["user_id"]; ok || anonymous["route"] != "/healthz" || anonymous["status"] != int64(http.StatusServiceUnavailable) ||
+		entries[1].Level != zapcore.ErrorLevel {
+		t.Fatalf("access log entry %v at %s", anonymous, entries[1].Level)
+	}
+}
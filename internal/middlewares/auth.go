@@ -13,27 +13,39 @@ import (
 
 type CtxType string
 
-const UserCtxName CtxType = "user"
+const (
+	UserCtxName  CtxType = "user"
+	TokenCtxName CtxType = "token"
+)
+
+// TokenDenylist tells whether an access token was revoked before its expiration.
+type TokenDenylist interface {
+	IsTokenRevoked(ctx context.Context, JTI string, UID string, generation int64) (bool, error)
+}
 
-func UserValidation(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// UserValidation decodes the user credentials and checks them with validate,
+// e.g. (*models.User).Validate for login or the registration policy.
+func UserValidation(validate func(*models.User) error) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		var user models.User
-		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-			helpers.HTTPError(w, err)
-			return
-		}
+			var user models.User
+			if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+				helpers.HTTPError(w, err)
+				return
+			}
 
-		if err := user.Validate(); err != nil {
-			helpers.HTTPError(w, err)
-			return
-		}
+			if err := validate(&user); err != nil {
+				helpers.ValidationError(w, err)
+				return
+			}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserCtxName, user)))
-	})
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserCtxName, user)))
+		})
+	}
 }
 
-func TokenValidation() func(next http.Handler) http.Handler {
+func TokenValidation(denylist TokenDenylist) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -43,13 +55,26 @@ func TokenValidation() func(next http.Handler) http.Handler {
 				return
 			}
 
-			uid, err := auth.GetUIDFromValidToken(token[1])
+			claims, err := auth.ParseToken(token[1])
 			if err != nil {
 				helpers.HTTPError(w, models.ErrUserUnauthorized)
 				return
 			}
 
-			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserCtxName, models.User{ID: uid})))
+			revoked, err := denylist.IsTokenRevoked(r.Context(), claims.JTI, claims.UID, claims.Generation)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				helpers.HTTPError(w, models.ErrTokenRevoked)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserCtxName, models.User{ID: claims.UID})
+			ctx = context.WithValue(ctx, TokenCtxName, claims)
+			ctx = setAccessLogUser(ctx, claims.UID)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -60,3 +85,10 @@ func GetUserFromCtx(ctx context.Context) (models.User, error) {
 	}
 	return models.User{}, models.ErrUserUnauthorized
 }
+
+func GetTokenFromCtx(ctx context.Context) (auth.Claims, error) {
+	if claims, ok := ctx.Value(TokenCtxName).(auth.Claims); ok {
+		return claims, nil
+	}
+	return auth.Claims{}, models.ErrUserUnauthorized
+}
@@ -0,0 +1,113 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/idempotency"
+	"github.com/stsg/gophermart2/internal/logger"
+	"github.com/stsg/gophermart2/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// Idempotency replays the stored response for requests repeated with the same
+// Idempotency-Key header. Requests without the header are passed through.
+// It must run after TokenValidation, keys are scoped to the user.
+func Idempotency(keys *idempotency.Keys) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				helpers.HTTPError(w, models.ErrInvalidIdempotencyKey)
+				return
+			}
+
+			user, err := GetUserFromCtx(r.Context())
+			if err != nil {
+				helpers.HTTPError(w, err)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				helpers.HTTPError(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record, replay, err := keys.Begin(r.Context(), user.ID, key, idempotency.Fingerprint(r, body))
+			if err != nil {
+				if errors.Is(err, models.ErrIdempotencyKeyReused) || errors.Is(err, models.ErrIdempotencyKeyInProgress) {
+					helpers.HTTPError(w, err)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if replay {
+				writeStoredResponse(w, record)
+				return
+			}
+
+			// The response is already produced, store it even if the client is gone.
+			ctx := context.Background()
+			defer func() {
+				if p := recover(); p != nil {
+					if err := keys.Release(ctx, user.ID, key); err != nil {
+						logger.FromContext(r.Context()).Warn("idempotency: release key", zap.Error(err))
+					}
+					panic(p)
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			// Server errors are not final, the client may retry with the same key.
+			if status >= http.StatusInternalServerError {
+				if err := keys.Release(ctx, user.ID, key); err != nil {
+					logger.FromContext(r.Context()).Warn("idempotency: release key", zap.Error(err))
+				}
+				return
+			}
+
+			record.StatusCode = &status
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = buf.Bytes()
+			if err := keys.Complete(ctx, record); err != nil {
+				logger.FromContext(r.Context()).Warn("idempotency: save response", zap.Error(err))
+			}
+		})
+	}
+}
+
+func writeStoredResponse(w http.ResponseWriter, record models.IdempotencyKey) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(*record.StatusCode)
+	w.Write(record.Body)
+}
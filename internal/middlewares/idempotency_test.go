@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/idempotency"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+)
+
+const idempotencyTestUID = "user"
+
+// newIdempotencyHandler wraps next in the Idempotency middleware backed by the
+// memory storage, with the user already authenticated.
+func newIdempotencyHandler(t *testing.T, lease time.Duration, next http.HandlerFunc) (http.Handler, *idempotency.Keys) {
+	t.Helper()
+	cfg := config.Defaults()
+	cfg.IdempotencyLeaseTTL = lease
+	config.Set(cfg)
+
+	keys := idempotency.New(memory.New())
+	h := Idempotency(keys)(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserCtxName, models.User{ID: idempotencyTestUID})))
+	}), keys
+}
+
+func newIdempotentRequest(key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader(body))
+	r.Header.Set(IdempotencyKeyHeader, key)
+	return r
+}
+
+func serve(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newIdempotentRequest(key, body))
+	return w
+}
+
+// servePanicking serves the request and returns the value the handler panicked with.
+func servePanicking(h http.Handler, key, body string) (p interface{}) {
+	defer func() { p = recover() }()
+	serve(h, key, body)
+	return nil
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls int32
+	h, _ := newIdempotencyHandler(t, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	first := serve(h, "key", "79927398713")
+	if first.Code != http.StatusAccepted || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("first response %d, headers %v", first.Code, first.Header())
+	}
+
+	second := serve(h, "key", "79927398713")
+	if second.Code != http.StatusAccepted || second.Body.String() != `{"ok":true}` ||
+		second.Header().Get("Content-Type") != "application/json" || second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("replayed response %d %q, headers %v", second.Code, second.Body, second.Header())
+	}
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want once", calls)
+	}
+
+	// without the header every request is handled
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader("79927398713")))
+	if w.Code != http.StatusAccepted || calls != 2 {
+		t.Fatalf("request without a key: %d, handler called %d times", w.Code, calls)
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	h, _ := newIdempotencyHandler(t, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	serve(h, "key", "79927398713")
+	if w := serve(h, "key", "4561261212345467"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("key reused with another body: %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := serve(h, strings.Repeat("k", maxIdempotencyKeyLength+1), "79927398713"); w.Code != http.StatusBadRequest {
+		t.Fatalf("too long key: %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	h, _ := newIdempotencyHandler(t, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(h, "key", "79927398713") }()
+	<-entered
+
+	if w := serve(h, "key", "79927398713"); w.Code != http.StatusConflict {
+		t.Fatalf("repeat while in progress: %d, want %d", w.Code, http.StatusConflict)
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusAccepted {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	if w := serve(h, "key", "79927398713"); w.Code != http.StatusAccepted || w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("repeat after completion: %d, headers %v", w.Code, w.Header())
+	}
+}
+
+func TestIdempotencyRelease(t *testing.T) {
+	tests := []struct {
+		name  string
+		serve func(h http.Handler)
+	}{
+		{"server error", func(h http.Handler) {
+			if w := serve(h, "key", "79927398713"); w.Code != http.StatusInternalServerError {
+				t.Fatalf("first request: %d", w.Code)
+			}
+		}},
+		{"panic", func(h http.Handler) {
+			if p := servePanicking(h, "key", "79927398713"); p == nil {
+				t.Fatal("panic not propagated")
+			}
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			h, _ := newIdempotencyHandler(t, time.Minute, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					if tt.name == "panic" {
+						panic("handler failed")
+					}
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+			})
+
+			tt.serve(h)
+			w := serve(h, "key", "79927398713")
+			if w.Code != http.StatusAccepted || w.Header().Get(IdempotentReplayedHeader) != "" || calls != 2 {
+				t.Fatalf("retry: %d, headers %v, handler called %d times", w.Code, w.Header(), calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyLeaseExpired(t *testing.T) {
+	const lease = 50 * time.Millisecond
+	h, keys := newIdempotencyHandler(t, lease, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	// a request reserved the key and never finished, e.g. the process crashed
+	r := newIdempotentRequest("key", "79927398713")
+	if _, _, err := keys.Begin(r.Context(), idempotencyTestUID, "key", idempotency.Fingerprint(r, []byte("79927398713"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(h, "key", "79927398713"); w.Code != http.StatusConflict {
+		t.Fatalf("repeat within the lease: %d, want %d", w.Code, http.StatusConflict)
+	}
+	time.Sleep(2 * lease)
+	if w := serve(h, "key", "79927398713"); w.Code != http.StatusAccepted || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("repeat after the lease: %d, headers %v", w.Code, w.Header())
+	}
+	// the completed key outlives the lease
+	time.Sleep(2 * lease)
+	if w := serve(h, "key", "79927398713"); w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("completed key not replayed after the lease: %d, headers %v", w.Code, w.Header())
+	}
+}
@@ -10,13 +10,40 @@ var (
 	ErrNoWithdrawals           = errors.New("you have no withdrawals")
 	ErrOrderAlreadyExists      = errors.New("this order already exists")
 	ErrOrderBelongsAnotherUser = errors.New("this order belongs to another user")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrWithdrawalAlreadyExists = errors.New("withdrawal for this order already exists")
 	ErrUserAlreadyExists       = errors.New("this user already exists")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrInvalidPassword         = errors.New("invalid password")
 
-	ErrUserUnauthorized    = errors.New("user unauthorized")
-	ErrInvalidLoginAttempt = errors.New("invalid username or password")
+	ErrUserUnauthorized     = errors.New("user unauthorized")
+	ErrInvalidLoginAttempt  = errors.New("invalid username or password")
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
 
 	ErrInvalidOrderNumber = errors.New("invalid order number")
+	ErrInvalidAmount      = errors.New("invalid amount")
+
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrInvalidListParams = errors.New("invalid list parameters")
+
+	ErrInvalidLastEventID = errors.New("invalid last event id")
+
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrUnknownAccrualStatus    = errors.New("unknown accrual status")
+	ErrInvalidStatusTransition = errors.New("invalid accrual status transition")
 
 	ErrInvalidBearerToken       = errors.New("invalid bearer token")
 	ErrInvalidBearerTokenFormat = errors.New("bearer token not in proper format")
+	ErrTokenRevoked             = errors.New("token revoked")
+	ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+	ErrRefreshTokenReused       = errors.New("refresh token reused")
+
+	ErrInvalidIdempotencyKey    = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+
+	ErrMigrationsPending = errors.New("database migrations pending")
 )
@@ -0,0 +1,30 @@
+package models
+
+type HealthStatus string
+
+const (
+	HealthStatusOK   HealthStatus = "ok"
+	HealthStatusFail HealthStatus = "fail"
+)
+
+type HealthCheck struct {
+	Status HealthStatus `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+func NewHealthCheck(err error) HealthCheck {
+	if err != nil {
+		return HealthCheck{Status: HealthStatusFail, Error: err.Error()}
+	}
+	return HealthCheck{Status: HealthStatusOK}
+}
+
+// Readiness is ok only if all its checks are ok.
+type Readiness struct {
+	Status HealthStatus           `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+func (r Readiness) IsReady() bool {
+	return r.Status == HealthStatusOK
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers a request made with the Idempotency-Key header and,
+// once it is handled, the response to replay for repeated requests.
+type IdempotencyKey struct {
+	UID         string    `db:"uid"`
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	StatusCode  *int      `db:"status_code"`
+	ContentType string    `db:"content_type"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+// IsCompleted reports whether the response is stored, i.e. the first request is not in flight.
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != nil
+}
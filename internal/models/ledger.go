@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type LedgerEntryKind string
+
+const (
+	LedgerEntryCredit LedgerEntryKind = "credit"
+	LedgerEntryDebit  LedgerEntryKind = "debit"
+)
+
+type LedgerAccount string
+
+const (
+	// LedgerAccountUser is the loyalty account of the user.
+	LedgerAccountUser LedgerAccount = "user"
+	// LedgerAccountAccrual pays the accruals for processed orders.
+	LedgerAccountAccrual LedgerAccount = "accrual"
+	// LedgerAccountWithdrawals receives the points the users withdraw.
+	LedgerAccountWithdrawals LedgerAccount = "withdrawals"
+)
+
+// LedgerEntry is one side of a movement of points: a credit of the user
+// account for a processed order or a debit for a withdrawal, balanced by the
+// counter-entry on the accrual or withdrawals account, so the entries of an
+// order sum to zero. There is at most one entry of each account and kind per
+// order, which makes crediting idempotent.
+type LedgerEntry struct {
+	UID       string          `db:"uid"`
+	Account   LedgerAccount   `db:"account"`
+	Kind      LedgerEntryKind `db:"kind"`
+	OrderID   string          `db:"order_id"`
+	Amount    Money           `db:"amount"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// CounterEntry returns the entry balancing the user account entry.
+func (e LedgerEntry) CounterEntry() LedgerEntry {
+	counter := e
+	if e.Kind == LedgerEntryCredit {
+		counter.Account, counter.Kind = LedgerAccountAccrual, LedgerEntryDebit
+	} else {
+		counter.Account, counter.Kind = LedgerAccountWithdrawals, LedgerEntryCredit
+	}
+	return counter
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// Cursor points at the last item of a page: the next page starts right after
+// it in the list order. Items are ordered by time, ties are broken by ID.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// Encode returns the opaque cursor representation passed to clients.
+func (c Cursor) Encode() string {
+	res, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(res)
+}
+
+func ParseCursor(s string) (c Cursor, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Page selects a slice of a list ordered by time. Zero Limit selects the
+// whole rest of the list.
+type Page struct {
+	Limit  int
+	After  *Cursor
+	Desc   bool
+	From   *time.Time
+	Before *time.Time
+}
+
+// Includes reports whether an item with the time and ID passes the time range
+// and comes after the cursor.
+func (p *Page) Includes(t time.Time, ID string) bool {
+	if p.From != nil && t.Before(*p.From) {
+		return false
+	}
+	if p.Before != nil && !t.Before(*p.Before) {
+		return false
+	}
+	if p.After == nil {
+		return true
+	}
+	if p.Desc {
+		return t.Before(p.After.Time) || t.Equal(p.After.Time) && ID < p.After.ID
+	}
+	return t.After(p.After.Time) || t.Equal(p.After.Time) && ID > p.After.ID
+}
+
+// Less orders items by time and ID in the page direction.
+func (p *Page) Less(ti time.Time, IDi string, tj time.Time, IDj string) bool {
+	if p.Desc {
+		ti, IDi, tj, IDj = tj, IDj, ti, IDi
+	}
+	return ti.Before(tj) || ti.Equal(tj) && IDi < IDj
+}
+
+// OrderFilter selects orders by status and upload time.
+type OrderFilter struct {
+	Page
+	Statuses []AccrualStatus
+}
+
+// WithdrawalFilter selects withdrawals by processing time.
+type WithdrawalFilter struct {
+	Page
+}
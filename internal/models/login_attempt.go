@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LoginAttempt counts recent failed logins for a key (login or client IP).
+type LoginAttempt struct {
+	Key         string     `db:"key"`
+	Failures    int        `db:"failures"`
+	LockedUntil *time.Time `db:"locked_until"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+}
+
+// LockedFor returns how long the key stays locked, zero if it is not.
+func (a *LoginAttempt) LockedFor() time.Duration {
+	if a.LockedUntil == nil {
+		return 0
+	}
+	if d := time.Until(*a.LockedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// moneyScale is the number of minor units in one point.
+const (
+	moneyScale     = 100
+	moneyPrecision = 2
+)
+
+// Money is an amount of loyalty points stored in minor units (hundredths),
+// so sums and comparisons are exact. It is encoded as a plain JSON number,
+// e.g. 729.98, and as numeric in the database.
+type Money int64
+
+// ParseMoney parses a decimal amount like "729.98". Digits beyond the
+// second decimal place are rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty amount", ErrInvalidAmount)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	if strings.Trim(intPart, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units > math.MaxInt64/moneyScale {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	var roundUp bool
+	if len(fracPart) > moneyPrecision {
+		if strings.Trim(fracPart, "0123456789") != "" {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+		roundUp = fracPart[moneyPrecision] >= '5'
+		fracPart = fracPart[:moneyPrecision]
+	}
+	fracPart += strings.Repeat("0", moneyPrecision-len(fracPart))
+
+	minor, err := strconv.ParseUint(fracPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if roundUp {
+		minor++
+	}
+	if units > (math.MaxInt64-int64(minor))/moneyScale {
+		return 0, fmt.Errorf("%w: %q overflows", ErrInvalidAmount, s)
+	}
+	m := Money(units*moneyScale + int64(minor))
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// MoneyFromFloat converts a float amount rounding it to the minor unit.
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * moneyScale))
+}
+
+func (m Money) Add(other Money) Money {
+	return m + other
+}
+
+func (m Money) Sub(other Money) Money {
+	return m - other
+}
+
+func (m Money) Less(other Money) bool {
+	return m < other
+}
+
+func (m Money) IsPositive() bool {
+	return m > 0
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// String formats the amount without trailing zeros: 500, 729.9, 729.98.
+func (m Money) String() string {
+	sign := ""
+	abs := uint64(m)
+	if m < 0 {
+		sign = "-"
+		// negated as unsigned, -m overflows for math.MinInt64
+		abs = -abs
+	}
+
+	units, minor := abs/moneyScale, abs%moneyScale
+	if minor == 0 {
+		return fmt.Sprintf("%s%d", sign, units)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%02d", sign, units, minor), "0")
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts JSON numbers only, amounts in strings are rejected.
+func (m *Money) UnmarshalJSON(data []byte) (err error) {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		return fmt.Errorf("%w: %s is not a number", ErrInvalidAmount, s)
+	}
+	*m, err = ParseMoney(s)
+	return
+}
+
+// Value implements driver.Valuer, the amount is passed as a numeric literal.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner for numeric, float and integer columns.
+func (m *Money) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case string:
+		*m, err = ParseMoney(v)
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case float64:
+		*m = MoneyFromFloat(v)
+	case int64:
+		*m = Money(v * moneyScale)
+	default:
+		err = fmt.Errorf("%w: unsupported type %T", ErrInvalidAmount, src)
+	}
+	return
+}
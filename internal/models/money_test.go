@@ -0,0 +1,155 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "729.98", want: 72998},
+		{in: "500", want: 50000},
+		{in: "0.5", want: 50},
+		{in: ".5", want: 50},
+		{in: "5.", want: 500},
+		{in: "+1.2", want: 120},
+		{in: " 1.2 ", want: 120},
+		{in: "-729.98", want: -72998},
+
+		// half away from zero
+		{in: "0.005", want: 1},
+		{in: "-0.005", want: -1},
+		{in: "0.004", want: 0},
+		{in: "1.235", want: 124},
+		{in: "-1.235", want: -124},
+		{in: "1.2349", want: 123},
+		{in: "0.995", want: 100},
+
+		{in: "1e2", want: 10000},
+		{in: "1.5E1", want: 1500},
+		{in: "-2.5e-1", want: -25},
+		{in: "1e-3", want: 0},
+
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "-92233720368547758.07", want: -math.MaxInt64},
+		{in: "92233720368547758.074", want: math.MaxInt64},
+		{in: "92233720368547758.075", wantErr: true},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "92233720368547759", wantErr: true},
+		{in: "1e20", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+		{in: "1e400", wantErr: true},
+
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1.x", wantErr: true},
+		{in: "1.23x", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "0x10", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("ParseMoney(%q) = %d, %v, want %v", tt.in, got, err, ErrInvalidAmount)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0"},
+		{50000, "500"},
+		{72990, "729.9"},
+		{72998, "729.98"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{-72998, "-729.98"},
+		{math.MaxInt64, "92233720368547758.07"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	for _, m := range []Money{0, 1, 50, 72998, -72998, 50000, math.MaxInt64} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Money
+		if err = json.Unmarshal(data, &got); err != nil || got != m {
+			t.Errorf("JSON round trip of %d via %s = %d, %v", int64(m), data, got, err)
+		}
+	}
+
+	var v struct {
+		Sum     Money  `json:"sum"`
+		Accrual *Money `json:"accrual"`
+	}
+	if err := json.Unmarshal([]byte(`{"sum": 751.005, "accrual": null}`), &v); err != nil || v.Sum != 75101 || v.Accrual != nil {
+		t.Fatalf("Unmarshal = %+v, %v", v, err)
+	}
+	for _, in := range []string{`{"sum": "751"}`, `{"sum": true}`, `{"sum": 1e30}`} {
+		if err := json.Unmarshal([]byte(in), &v); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Unmarshal(%s) = %v, want %v", in, err, ErrInvalidAmount)
+		}
+	}
+}
+
+func TestMoneySQL(t *testing.T) {
+	for _, m := range []Money{0, 1, 72998, -72998, math.MinInt64 + 1} {
+		value, err := m.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Money
+		if err = got.Scan(value); err != nil || got != m {
+			t.Errorf("SQL round trip of %d via %v = %d, %v", int64(m), value, got, err)
+		}
+		if err = got.Scan([]byte(value.(string))); err != nil || got != m {
+			t.Errorf("Scan of %q bytes = %d, %v", value, got, err)
+		}
+	}
+
+	tests := []struct {
+		src  interface{}
+		want Money
+	}{
+		{nil, 0},
+		{"729.98", 72998},
+		{729.98, 72998},
+		{int64(7), 700},
+	}
+	for _, tt := range tests {
+		var got Money
+		if err := got.Scan(tt.src); err != nil || got != tt.want {
+			t.Errorf("Scan(%#v) = %d, %v, want %d", tt.src, got, err, tt.want)
+		}
+	}
+	var m Money
+	if err := m.Scan(true); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Scan(true) = %v, want %v", err, ErrInvalidAmount)
+	}
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -14,10 +15,64 @@ const (
 	AccrualStatusInvalid    AccrualStatus = "INVALID"
 )
 
+// ExternalAccrualStatus is the order status reported by the accrual system.
+type ExternalAccrualStatus string
+
+const (
+	ExternalAccrualStatusRegistered ExternalAccrualStatus = "REGISTERED"
+	ExternalAccrualStatusProcessing ExternalAccrualStatus = "PROCESSING"
+	ExternalAccrualStatusInvalid    ExternalAccrualStatus = "INVALID"
+	ExternalAccrualStatusProcessed  ExternalAccrualStatus = "PROCESSED"
+)
+
+var externalAccrualStatuses = map[ExternalAccrualStatus]AccrualStatus{
+	ExternalAccrualStatusRegistered: AccrualStatusNew,
+	ExternalAccrualStatusProcessing: AccrualStatusProcessing,
+	ExternalAccrualStatusInvalid:    AccrualStatusInvalid,
+	ExternalAccrualStatusProcessed:  AccrualStatusProcessed,
+}
+
+// Internal translates the accrual system status to the order status.
+func (s ExternalAccrualStatus) Internal() (AccrualStatus, error) {
+	status, ok := externalAccrualStatuses[s]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownAccrualStatus, s)
+	}
+	return status, nil
+}
+
+// IsValid reports whether s is one of the known order statuses.
+func (s AccrualStatus) IsValid() bool {
+	switch s {
+	case AccrualStatusNew, AccrualStatusProcessing, AccrualStatusProcessed, AccrualStatusInvalid:
+		return true
+	}
+	return false
+}
+
+// IsFinal reports whether the order can no longer change its status.
+func (s AccrualStatus) IsFinal() bool {
+	return s == AccrualStatusProcessed || s == AccrualStatusInvalid
+}
+
+// CanTransitionTo reports whether the order may move from s to next.
+// Final statuses are never left, and an order never goes back to NEW once
+// it is being processed. Staying in the same non-final status is allowed.
+func (s AccrualStatus) CanTransitionTo(next AccrualStatus) bool {
+	switch s {
+	case AccrualStatusNew:
+		return next == AccrualStatusNew || next == AccrualStatusProcessing || next.IsFinal()
+	case AccrualStatusProcessing:
+		return next == AccrualStatusProcessing || next.IsFinal()
+	default:
+		return false
+	}
+}
+
 type Order struct {
 	ID            string        `json:"number" db:"id"`
 	UID           string        `json:"-" db:"uid"`
-	Accrual       *float64      `json:"accrual,omitempty" db:"accrual"`
+	Accrual       *Money        `json:"accrual,omitempty" db:"accrual"`
 	AccrualStatus AccrualStatus `json:"status" db:"accrual_status"`
 	UploadedAt    time.Time     `json:"uploaded_at" db:"uploaded_at"`
 }
@@ -26,3 +81,21 @@ func (o *Order) Marshal() (res []byte, err error) {
 	res, err = json.Marshal(o)
 	return
 }
+
+// Transition moves the order to the status reported by the accrual system.
+// An accrual is only taken along with the PROCESSED status.
+func (o *Order) Transition(external ExternalAccrualStatus, accrual *Money) error {
+	next, err := external.Internal()
+	if err != nil {
+		return err
+	}
+	if !o.AccrualStatus.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, o.AccrualStatus, next)
+	}
+
+	o.AccrualStatus = next
+	if next == AccrualStatusProcessed {
+		o.Accrual = accrual
+	}
+	return nil
+}
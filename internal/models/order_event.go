@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// OrderEvent records a change of the order status or accrual. Events are
+// numbered in the order they happen, clients resume streams by the last ID.
+type OrderEvent struct {
+	ID        int64         `json:"-" db:"id"`
+	UID       string        `json:"-" db:"uid"`
+	OrderID   string        `json:"number" db:"order_id"`
+	Status    AccrualStatus `json:"status" db:"status"`
+	Accrual   *Money        `json:"accrual,omitempty" db:"accrual"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
+
+func NewOrderEvent(order Order) OrderEvent {
+	return OrderEvent{
+		UID:     order.UID,
+		OrderID: order.ID,
+		Status:  order.AccrualStatus,
+		Accrual: order.Accrual,
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	const (
+		newStatus  = AccrualStatusNew
+		processing = AccrualStatusProcessing
+		processed  = AccrualStatusProcessed
+		invalid    = AccrualStatusInvalid
+	)
+	allowed := map[AccrualStatus]map[AccrualStatus]bool{
+		newStatus:  {newStatus: true, processing: true, processed: true, invalid: true},
+		processing: {newStatus: false, processing: true, processed: true, invalid: true},
+		processed:  {newStatus: false, processing: false, processed: false, invalid: false},
+		invalid:    {newStatus: false, processing: false, processed: false, invalid: false},
+	}
+	for from, targets := range allowed {
+		for to, want := range targets {
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v, want %v", from, to, got, want)
+			}
+		}
+		if from.CanTransitionTo("LOST") {
+			t.Errorf("%s -> unknown status allowed", from)
+		}
+		if want := from == processed || from == invalid; from.IsFinal() != want {
+			t.Errorf("%s final = %v, want %v", from, from.IsFinal(), want)
+		}
+	}
+}
+
+func TestExternalAccrualStatusInternal(t *testing.T) {
+	tests := []struct {
+		external ExternalAccrualStatus
+		want     AccrualStatus
+		wantErr  error
+	}{
+		{ExternalAccrualStatusRegistered, AccrualStatusNew, nil},
+		{ExternalAccrualStatusProcessing, AccrualStatusProcessing, nil},
+		{ExternalAccrualStatusProcessed, AccrualStatusProcessed, nil},
+		{ExternalAccrualStatusInvalid, AccrualStatusInvalid, nil},
+		{"NEW", "", ErrUnknownAccrualStatus},
+		{"processed", "", ErrUnknownAccrualStatus},
+		{"", "", ErrUnknownAccrualStatus},
+	}
+	for _, tt := range tests {
+		got, err := tt.external.Internal()
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%q.Internal() = %q, %v, want %q, %v", tt.external, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestOrderTransition(t *testing.T) {
+	accrual := Money(500)
+	tests := []struct {
+		name        string
+		from        AccrualStatus
+		external    ExternalAccrualStatus
+		want        AccrualStatus
+		wantAccrual bool
+		wantErr     error
+	}{
+		{"registered", AccrualStatusNew, ExternalAccrualStatusRegistered, AccrualStatusNew, false, nil},
+		{"processing", AccrualStatusNew, ExternalAccrualStatusProcessing, AccrualStatusProcessing, false, nil},
+		{"processed takes the accrual", AccrualStatusProcessing, ExternalAccrualStatusProcessed, AccrualStatusProcessed, true, nil},
+		{"invalid drops the accrual", AccrualStatusProcessing, ExternalAccrualStatusInvalid, AccrualStatusInvalid, false, nil},
+		{"back to new", AccrualStatusProcessing, ExternalAccrualStatusRegistered, AccrualStatusProcessing, false, ErrInvalidStatusTransition},
+		{"processed is terminal", AccrualStatusProcessed, ExternalAccrualStatusProcessing, AccrualStatusProcessed, false, ErrInvalidStatusTransition},
+		{"invalid is terminal", AccrualStatusInvalid, ExternalAccrualStatusProcessed, AccrualStatusInvalid, false, ErrInvalidStatusTransition},
+		{"unknown status", AccrualStatusNew, "LOST", AccrualStatusNew, false, ErrUnknownAccrualStatus},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{ID: "79927398713", AccrualStatus: tt.from}
+			err := order.Transition(tt.external, &accrual)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition error %v, want %v", err, tt.wantErr)
+			}
+			if order.AccrualStatus != tt.want || (order.Accrual != nil) != tt.wantAccrual {
+				t.Fatalf("order after transition = %+v", order)
+			}
+		})
+	}
+}
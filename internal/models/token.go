@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RefreshToken is a persisted refresh token. Only the token hash is stored.
+// Every rotation creates a new token in the same family and marks the old one
+// as replaced, so presenting a replaced token reveals a reuse.
+type RefreshToken struct {
+	ID         string     `db:"id"`
+	FamilyID   string     `db:"family_id"`
+	UID        string     `db:"uid"`
+	TokenHash  string     `db:"token_hash"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	ReplacedBy *string    `db:"replaced_by"`
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
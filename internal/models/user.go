@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/go-ozzo/ozzo-validation/v4"
+	"go.uber.org/zap/zapcore"
 )
 
 type User struct {
@@ -11,8 +12,23 @@ type User struct {
 	Login     string    `json:"login" db:"login"`
 	Password  string    `json:"password" db:"password"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// TokenGeneration grows whenever all issued tokens are revoked. Access
+	// tokens carry the generation they were issued for.
+	TokenGeneration int64 `json:"-" db:"token_generation"`
+}
+
+type PasswordChange struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// MarshalLogObject logs the user without the password.
+func (u User) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", u.ID)
+	enc.AddString("login", u.Login)
+	return nil
 }
 
 func (u *User) Validate() error {
-	return validation.ValidateStruct(u, validation.Field(&u.Login), validation.Field(&u.Password, validation.Required))
+	return validation.ValidateStruct(u, validation.Field(&u.Login, validation.Required), validation.Field(&u.Password, validation.Required))
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type WebhookEventType string
+
+const (
+	WebhookEventOrderUpdated      WebhookEventType = "order.updated"
+	WebhookEventWithdrawalCreated WebhookEventType = "withdrawal.created"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// Webhook is an endpoint notified about the events of its user. Requests are
+// signed with the secret, which is shown only when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id" db:"id"`
+	UID       string    `json:"-" db:"uid"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+func (w *Webhook) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.URL, validation.Required, validation.Length(1, 2048), validation.By(isHTTPURL)),
+		validation.Field(&w.Secret, validation.Length(16, 256)),
+	)
+}
+
+func isHTTPURL(value interface{}) error {
+	u, err := url.Parse(value.(string))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return validation.NewError("validation_webhook_url", "must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// WebhookDelivery is an event waiting to be delivered to a webhook, or the
+// log record of its delivery. Deliveries are written in the transaction that
+// produces the event, which makes the table a transactional outbox.
+type WebhookDelivery struct {
+	ID             string                `json:"id" db:"id"`
+	WebhookID      string                `json:"webhook_id" db:"webhook_id"`
+	UID            string                `json:"-" db:"uid"`
+	EventType      WebhookEventType      `json:"event" db:"event_type"`
+	Payload        RawJSON               `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string               `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WithdrawalEvent is the payload of WebhookEventWithdrawalCreated.
+type WithdrawalEvent struct {
+	OrderID     string    `json:"order"`
+	Amount      Money     `json:"sum"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// RawJSON is an encoded JSON value kept as is, stored in json and jsonb columns.
+type RawJSON json.RawMessage
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *RawJSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner.
+func (j *RawJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*j = nil
+	case string:
+		*j = RawJSON(v)
+	case []byte:
+		*j = append(RawJSON(nil), v...)
+	default:
+		return fmt.Errorf("raw json: unsupported type %T", src)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"bufio"
+	_ "embed"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxPasswordLength is the bcrypt input limit, longer passwords are silently truncated by it.
+const maxPasswordLength = 72
+
+var (
+	//go:embed banned_passwords.txt
+	bannedPasswordsFile string
+
+	onceInstance sync.Once
+	instance     *Policy
+)
+
+var (
+	errLoginCharset    = validation.NewError("validation_login_charset", "contains not allowed characters")
+	errPasswordClasses = validation.NewError("validation_password_classes", "must contain at least {{.min}} of: lowercase letters, uppercase letters, digits, symbols")
+	errPasswordBanned  = validation.NewError("validation_password_banned", "is too common")
+	errPasswordIsLogin = validation.NewError("validation_password_is_login", "must not contain the login")
+)
+
+// Policy holds the rules new users must satisfy on registration.
+type Policy struct {
+	loginMinLength     int
+	loginMaxLength     int
+	loginPattern       *regexp.Regexp
+	passwordMinLength  int
+	passwordMinClasses int
+	bannedPasswords    map[string]struct{}
+}
+
+func New() {
+	onceInstance.Do(func() {
+		cfg := config.Get()
+		loginPattern, err := regexp.Compile(cfg.LoginPattern)
+		if err != nil {
+			zap.L().Fatal("password policy: compile login pattern", zap.Error(err))
+		}
+
+		instance = &Policy{
+			loginMinLength:     cfg.LoginMinLength,
+			loginMaxLength:     cfg.LoginMaxLength,
+			loginPattern:       loginPattern,
+			passwordMinLength:  cfg.PasswordMinLength,
+			passwordMinClasses: cfg.PasswordMinClasses,
+			bannedPasswords:    parseBannedPasswords(bannedPasswordsFile),
+		}
+	})
+}
+
+func Get() *Policy {
+	New()
+	return instance
+}
+
+// Validate checks the user being registered. The error is validation.Errors
+// keyed by the JSON field names.
+func (p *Policy) Validate(u *models.User) error {
+	return validation.ValidateStruct(u,
+		validation.Field(&u.Login,
+			validation.Required,
+			validation.RuneLength(p.loginMinLength, p.loginMaxLength),
+			validation.Match(p.loginPattern).ErrorObject(errLoginCharset),
+		),
+		validation.Field(&u.Password,
+			validation.Required,
+			validation.RuneLength(p.passwordMinLength, 0),
+			validation.Length(0, maxPasswordLength),
+			validation.By(p.checkClasses),
+			validation.By(p.checkBanned),
+			validation.By(func(value interface{}) error {
+				if u.Login != "" && strings.Contains(strings.ToLower(value.(string)), strings.ToLower(u.Login)) {
+					return errPasswordIsLogin
+				}
+				return nil
+			}),
+		),
+	)
+}
+
+func (p *Policy) checkClasses(value interface{}) error {
+	var lower, upper, digit, symbol bool
+	for _, r := range value.(string) {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.passwordMinClasses {
+		return errPasswordClasses.SetParams(map[string]interface{}{"min": p.passwordMinClasses})
+	}
+	return nil
+}
+
+func (p *Policy) checkBanned(value interface{}) error {
+	if _, ok := p.bannedPasswords[strings.ToLower(value.(string))]; ok {
+		return errPasswordBanned
+	}
+	return nil
+}
+
+func parseBannedPasswords(data string) map[string]struct{} {
+	res := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			res[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return res
+}
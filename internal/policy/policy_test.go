@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/helpers"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// newTestPolicy returns the policy with the default settings.
+func newTestPolicy() *Policy {
+	cfg := config.Defaults()
+	return &Policy{
+		loginMinLength:     cfg.LoginMinLength,
+		loginMaxLength:     cfg.LoginMaxLength,
+		loginPattern:       regexp.MustCompile(cfg.LoginPattern),
+		passwordMinLength:  cfg.PasswordMinLength,
+		passwordMinClasses: cfg.PasswordMinClasses,
+		bannedPasswords:    parseBannedPasswords(bannedPasswordsFile),
+	}
+}
+
+// errorCodes returns the validation error code of every invalid field.
+func errorCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	res := map[string]string{}
+	if err == nil {
+		return res
+	}
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error %v is not validation.Errors", err)
+	}
+	for field, fieldErr := range errs {
+		var e validation.Error
+		if !errors.As(fieldErr, &e) {
+			t.Fatalf("%s: error %v has no code", field, fieldErr)
+		}
+		res[field] = e.Code()
+	}
+	return res
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		want     map[string]string
+	}{
+		{"valid", "alice", "Zebra7!x", map[string]string{}},
+		{"valid multi-byte", "alice", "Пароль-1", map[string]string{}},
+
+		{"blank", "", "", map[string]string{"login": "validation_required", "password": "validation_required"}},
+		{"short login", "al", "Zebra7!x", map[string]string{"login": "validation_length_out_of_range"}},
+		{"long login", strings.Repeat("a", 65), "Zebra7!x", map[string]string{"login": "validation_length_out_of_range"}},
+		{"login charset", "alice smith", "Zebra7!x", map[string]string{"login": "validation_login_charset"}},
+
+		{"short password", "alice", "Zebra7!", map[string]string{"password": "validation_length_too_short"}},
+		// 7 runes in 19 bytes: the minimum counts characters
+		{"short multi-byte password", "alice", "€€€€€€1", map[string]string{"password": "validation_length_too_short"}},
+		{"72 bytes", "alice", strings.Repeat("Ab1", 24), map[string]string{}},
+		{"73 bytes", "alice", strings.Repeat("Ab1", 24) + "x", map[string]string{"password": "validation_length_too_long"}},
+		// 25 runes in 73 bytes: the maximum counts bytes, bcrypt would truncate the rest
+		{"long multi-byte password", "alice", strings.Repeat("€", 24) + "1", map[string]string{"password": "validation_length_too_long"}},
+
+		{"one class", "alice", "zebrazebra", map[string]string{"password": "validation_password_classes"}},
+		{"digits only", "alice", "73915284", map[string]string{"password": "validation_password_classes"}},
+		{"lower and digit", "alice", "zebra7zebra", map[string]string{}},
+		{"lower and symbol", "alice", "zebra-zebra", map[string]string{}},
+		{"upper and lower", "alice", "ZebraZebra", map[string]string{}},
+
+		{"banned", "alice", "password1", map[string]string{"password": "validation_password_banned"}},
+		{"banned in another case", "alice", "QWERTY123", map[string]string{"password": "validation_password_banned"}},
+
+		{"password contains login", "alice", "xxAlice2022", map[string]string{"password": "validation_password_is_login"}},
+		{"password is login", "alice.smith1", "Alice.Smith1", map[string]string{"password": "validation_password_is_login"}},
+	}
+	p := newTestPolicy()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := errorCodes(t, p.Validate(&models.User{Login: tt.login, Password: tt.password}))
+			if len(got) != len(tt.want) {
+				t.Fatalf("errors %v, want %v", got, tt.want)
+			}
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Fatalf("errors %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	err := newTestPolicy().Validate(&models.User{Login: "al", Password: "zebrazebra"})
+
+	w := httptest.NewRecorder()
+	helpers.ValidationError(w, err)
+	if w.Code != http.StatusBadRequest || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("response %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var body struct {
+		Errors map[string]string `json:"errors"`
+	}
+	if err = json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %s: %v", w.Body, err)
+	}
+	want := map[string]string{
+		"login":    "the length must be between 3 and 64",
+		"password": "must contain at least 2 of: lowercase letters, uppercase letters, digits, symbols",
+	}
+	if len(body.Errors) != len(want) || body.Errors["login"] != want["login"] || body.Errors["password"] != want["password"] {
+		t.Fatalf("errors %v, want %v", body.Errors, want)
+	}
+}
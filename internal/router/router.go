@@ -5,8 +5,13 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stsg/gophermart2/internal/config"
 	"github.com/stsg/gophermart2/internal/handlers"
+	"github.com/stsg/gophermart2/internal/metrics"
 	"github.com/stsg/gophermart2/internal/middlewares"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/policy"
 	gophermart "github.com/stsg/gophermart2/internal/services/gophermart"
 )
 
@@ -16,32 +21,54 @@ func New(ctx context.Context) chi.Router {
 
 	r.Use(
 		middleware.RequestID,
+		middlewares.Tracing,
 		middleware.RealIP,
-		middleware.Logger,
+		middlewares.Metrics,
+		middlewares.AccessLog,
 		middleware.Recoverer,
-		middleware.Compress(5),
+		middleware.Compress(config.Get().CompressionLevel),
 		middlewares.Decompress,
 	)
+	r.Get("/healthz", handlers.Healthz())
+	r.Get("/readyz", handlers.Readyz(g))
+	r.Get("/.well-known/jwks.json", handlers.JWKS())
 	r.Route("/api/user", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
-			r.Use(middlewares.TokenValidation())
+			r.Use(middlewares.TokenValidation(g.Storage))
 
 			r.Route("/balance", func(r chi.Router) {
 				r.Get("/", handlers.GetBalance(g))
-				r.Post("/withdraw", handlers.Withdraw(g))
+				r.With(middlewares.Idempotency(g.Idempotency)).Post("/withdraw", handlers.Withdraw(g))
 			})
 			r.Route("/orders", func(r chi.Router) {
 				r.Get("/", handlers.GetOrders(g))
-				r.Post("/", handlers.ProcessOrder(g))
+				r.With(middlewares.Idempotency(g.Idempotency)).Post("/", handlers.ProcessOrder(g))
+				r.Get("/events", handlers.OrderEvents(g))
+				r.Get("/{number}", handlers.GetOrder(g))
+			})
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", handlers.GetWebhooks(g))
+				r.Post("/", handlers.AddWebhook(g))
+				r.Delete("/{id}", handlers.DeleteWebhook(g))
+				r.Get("/{id}/deliveries", handlers.GetWebhookDeliveries(g))
+				r.Post("/{id}/deliveries/{deliveryID}/retry", handlers.RetryWebhookDelivery(g))
 			})
 			r.Get("/withdrawals", handlers.GetWithdrawals(g))
+			r.Post("/logout", handlers.Logout(g))
+			r.Put("/password", handlers.ChangePassword(g))
+			r.Delete("/", handlers.DeleteUser(g))
 		})
-		r.Group(func(r chi.Router) {
-			r.Use(middlewares.UserValidation)
-
-			r.Post("/login", handlers.Login(g))
-			r.Post("/register", handlers.Register(g))
-		})
+		r.With(middlewares.UserValidation((*models.User).Validate)).Post("/login", handlers.Login(g))
+		r.With(middlewares.UserValidation(policy.Get().Validate)).Post("/register", handlers.Register(g))
+		r.Post("/token/refresh", handlers.RefreshToken(g))
 	})
 	return r
 }
+
+// NewAdmin serves the operational endpoints, which must not be reachable
+// through the public address.
+func NewAdmin() chi.Router {
+	r := chi.NewRouter()
+	r.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	return r
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/events"
 	"github.com/stsg/gophermart2/internal/router"
 	"github.com/stsg/gophermart2/internal/services/shutdowner"
 	"go.uber.org/zap"
@@ -15,12 +16,15 @@ type Server struct {
 }
 
 func New(ctx context.Context) *Server {
-	return &Server{
+	s := &Server{
 		http: http.Server{
 			Addr:    config.Get().RunAddress,
 			Handler: router.New(ctx),
 		},
 	}
+	// Shutdown waits for active connections, end the event streams first.
+	s.http.RegisterOnShutdown(events.Get().Close)
+	return s
 }
 
 func Run(ctx context.Context) {
@@ -29,6 +33,23 @@ func Run(ctx context.Context) {
 	srv.addToShutdowner()
 }
 
+// RunAdmin serves the admin router on config.AdminAddress, unless it is empty.
+func RunAdmin(_ context.Context) {
+	addr := config.Get().AdminAddress
+	if addr == "" {
+		return
+	}
+
+	srv := &Server{
+		http: http.Server{
+			Addr:    addr,
+			Handler: router.NewAdmin(),
+		},
+	}
+	go srv.ListenAndServer()
+	srv.addToShutdowner()
+}
+
 func (s *Server) ListenAndServer() {
 	if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		zap.L().Fatal(err.Error())
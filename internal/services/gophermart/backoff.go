@@ -0,0 +1,32 @@
+package gophermart2
+
+import (
+	"sync"
+	"time"
+)
+
+// backoff is shared by every accrual request: once the accrual system asks
+// to slow down, no order is polled until the window expires.
+type backoff struct {
+	mu    sync.RWMutex
+	until time.Time
+}
+
+// pause extends the window to d from now. A shorter window never cuts an active one.
+func (b *backoff) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.until) {
+		b.until = until
+	}
+}
+
+// remaining returns how long polling must still wait, zero if it may proceed.
+func (b *backoff) remaining() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if d := time.Until(b.until); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package gophermart2
+
+import (
+	"context"
+
+	"github.com/stsg/gophermart2/internal/luhn"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+func (g *Gophermart) GetBalance(ctx context.Context, UID string) (models.Balance, error) {
+	return g.Storage.GetBalanceByUID(ctx, UID)
+}
+
+// Withdraw debits the user account in favour of a new order.
+func (g *Gophermart) Withdraw(ctx context.Context, withdrawal models.Withdrawal) error {
+	if !luhn.Valid(withdrawal.OrderID) {
+		return models.ErrInvalidOrderNumber
+	}
+	if !withdrawal.Amount.IsPositive() {
+		return models.ErrInvalidAmount
+	}
+
+	return g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		balance, err := g.Storage.GetCurrentBalanceByUID(ctx, withdrawal.UID)
+		if err != nil {
+			return err
+		}
+
+		if balance.Less(withdrawal.Amount) {
+			return models.ErrInsufficientFunds
+		}
+
+		stored, err := g.Storage.AddWithdrawal(ctx, withdrawal)
+		if err != nil {
+			return err
+		}
+
+		if _, err = g.Storage.AddLedgerEntry(ctx, models.LedgerEntry{
+			UID:     withdrawal.UID,
+			Kind:    models.LedgerEntryDebit,
+			OrderID: withdrawal.OrderID,
+			Amount:  withdrawal.Amount,
+		}); err != nil {
+			return err
+		}
+
+		if err = g.Storage.ReconcileBalanceByUID(ctx, withdrawal.UID); err != nil {
+			return err
+		}
+
+		return g.enqueueWebhookEvent(ctx, withdrawal.UID, models.WebhookEventWithdrawalCreated, models.WithdrawalEvent{
+			OrderID:     withdrawal.OrderID,
+			Amount:      withdrawal.Amount,
+			ProcessedAt: stored.ProcessedAt,
+		})
+	})
+}
+
+// ListWithdrawals returns a page of the user withdrawals and the cursor of the
+// next page, nil if this page is the last one.
+func (g *Gophermart) ListWithdrawals(ctx context.Context, UID string, filter models.WithdrawalFilter) (withdrawals []models.Withdrawal, next *models.Cursor, err error) {
+	limit := filter.Limit
+	if limit > 0 {
+		filter.Limit++
+	}
+	if withdrawals, err = g.Storage.GetWithdrawalsByUID(ctx, UID, filter); err != nil {
+		return
+	}
+
+	if limit > 0 && len(withdrawals) > limit {
+		withdrawals = withdrawals[:limit]
+		last := withdrawals[limit-1]
+		next = &models.Cursor{Time: last.ProcessedAt, ID: last.OrderID}
+	}
+	return
+}
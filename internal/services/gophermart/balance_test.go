@@ -0,0 +1,205 @@
+package gophermart2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+	"github.com/stsg/gophermart2/internal/storages/postgres"
+)
+
+// testDatabaseURIEnv names the database the Postgres tests migrate and write
+// to. They are skipped without it.
+const testDatabaseURIEnv = "TEST_DATABASE_URI"
+
+const (
+	concurrentWithdrawals = 300
+	withdrawalsInFlight   = 32
+)
+
+func TestWithdrawInsufficientFunds(t *testing.T) {
+	g, s := newMockGophermart(t)
+	ctx := context.Background()
+	withdrawal := models.Withdrawal{UID: "user", OrderID: luhnNumber(t, 1), Amount: 500}
+
+	s.EXPECT().GetCurrentBalanceByUID(gomock.Any(), "user").Return(models.Money(499), nil)
+
+	if err := g.Withdraw(ctx, withdrawal); !errors.Is(err, models.ErrInsufficientFunds) {
+		t.Fatalf("Withdraw = %v, want %v", err, models.ErrInsufficientFunds)
+	}
+}
+
+func TestWithdrawInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		withdrawal models.Withdrawal
+		want       error
+	}{
+		{"zero amount", models.Withdrawal{UID: "user", OrderID: "79927398713", Amount: 0}, models.ErrInvalidAmount},
+		{"negative amount", models.Withdrawal{UID: "user", OrderID: "79927398713", Amount: -100}, models.ErrInvalidAmount},
+		{"bad order number", models.Withdrawal{UID: "user", OrderID: "79927398710", Amount: 100}, models.ErrInvalidOrderNumber},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// the mock fails the test on any storage call
+			g, _ := newMockGophermart(t)
+			if err := g.Withdraw(context.Background(), tt.withdrawal); !errors.Is(err, tt.want) {
+				t.Fatalf("Withdraw = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListWithdrawalsPaging(t *testing.T) {
+	g, s := newMockGophermart(t)
+	ctx := context.Background()
+	now := time.Now()
+	withdrawals := []models.Withdrawal{
+		{UID: "user", OrderID: "1", ProcessedAt: now},
+		{UID: "user", OrderID: "2", ProcessedAt: now.Add(time.Second)},
+		{UID: "user", OrderID: "3", ProcessedAt: now.Add(2 * time.Second)},
+	}
+
+	// one extra item is asked for to tell whether a next page exists
+	s.EXPECT().GetWithdrawalsByUID(gomock.Any(), "user", models.WithdrawalFilter{Page: models.Page{Limit: 3}}).Return(withdrawals, nil)
+	page, next, err := g.ListWithdrawals(ctx, "user", models.WithdrawalFilter{Page: models.Page{Limit: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[1].OrderID != "2" {
+		t.Fatalf("page = %+v", page)
+	}
+	if next == nil || next.ID != "2" || !next.Time.Equal(withdrawals[1].ProcessedAt) {
+		t.Fatalf("next = %+v, want the cursor of the second withdrawal", next)
+	}
+
+	s.EXPECT().GetWithdrawalsByUID(gomock.Any(), "user", models.WithdrawalFilter{Page: models.Page{Limit: 3, After: next}}).Return(withdrawals[2:], nil)
+	page, next, err = g.ListWithdrawals(ctx, "user", models.WithdrawalFilter{Page: models.Page{Limit: 2, After: next}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].OrderID != "3" || next != nil {
+		t.Fatalf("last page = %+v, next = %+v", page, next)
+	}
+
+	s.EXPECT().GetWithdrawalsByUID(gomock.Any(), "user", models.WithdrawalFilter{}).Return(withdrawals, nil)
+	if page, next, err = g.ListWithdrawals(ctx, "user", models.WithdrawalFilter{}); err != nil || len(page) != 3 || next != nil {
+		t.Fatalf("unlimited list = %+v, %+v, %v", page, next, err)
+	}
+}
+
+func TestConcurrentWithdrawals(t *testing.T) {
+	tests := []struct {
+		name       string
+		newStorage func(t *testing.T) storages.Storager
+	}{
+		{"memory", func(t *testing.T) storages.Storager {
+			return memory.New()
+		}},
+		{"postgres", func(t *testing.T) storages.Storager {
+			uri := os.Getenv(testDatabaseURIEnv)
+			if uri == "" {
+				t.Skipf("%s is not set", testDatabaseURIEnv)
+			}
+			cfg := config.Defaults()
+			cfg.DatabaseURI = uri
+			cfg.DatabaseQueryTimeout = 10 * time.Second
+			config.Set(cfg)
+			return postgres.New(context.Background())
+		}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			testConcurrentWithdrawals(t, tt.newStorage(t))
+		})
+	}
+}
+
+// testConcurrentWithdrawals races more withdrawals than the balance covers
+// and checks that exactly the covered ones succeed.
+func testConcurrentWithdrawals(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	g := &Gophermart{Storage: s}
+
+	user := models.User{ID: uuid.NewString(), Login: "user-" + uuid.NewString(), Password: "hash"}
+	if err := s.AddUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		credited = models.Money(100_00)
+		amount   = models.Money(1_00)
+	)
+	if _, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: user.ID, Kind: models.LedgerEntryCredit, OrderID: luhnNumber(t, 0), Amount: credited}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReconcileBalanceByUID(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg                    sync.WaitGroup
+		mu                    sync.Mutex
+		succeeded, overdrafts int
+		failures              []error
+	)
+	inFlight := make(chan struct{}, withdrawalsInFlight)
+	for i := 1; i <= concurrentWithdrawals; i++ {
+		orderID := luhnNumber(t, i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			inFlight <- struct{}{}
+			defer func() { <-inFlight }()
+
+			err := g.Withdraw(ctx, models.Withdrawal{UID: user.ID, OrderID: orderID, Amount: amount})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, models.ErrInsufficientFunds):
+				overdrafts++
+			default:
+				failures = append(failures, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		t.Fatalf("%d withdrawals failed, first: %v", len(failures), failures[0])
+	}
+	want := int(credited / amount)
+	if succeeded != want || overdrafts != concurrentWithdrawals-want {
+		t.Fatalf("succeeded %d, overdrafts %d, want %d and %d", succeeded, overdrafts, want, concurrentWithdrawals-want)
+	}
+
+	balance, err := g.GetBalance(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Current != 0 || balance.Withdrawn != credited {
+		t.Fatalf("balance = %+v, want nothing left and %v withdrawn", balance, credited)
+	}
+
+	withdrawals, _, err := g.ListWithdrawals(ctx, user.ID, models.WithdrawalFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals) != want {
+		t.Fatalf("%d withdrawals listed, want %d", len(withdrawals), want)
+	}
+}
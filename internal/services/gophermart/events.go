@@ -0,0 +1,42 @@
+package gophermart2
+
+import (
+	"context"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	eventLogCleanupInterval = time.Hour
+	eventLogPageSize        = 100
+)
+
+// ListOrderEvents returns the next logged events of the user after the event
+// with afterID, to resume a stream.
+func (g *Gophermart) ListOrderEvents(ctx context.Context, UID string, afterID int64) ([]models.OrderEvent, error) {
+	return g.Storage.GetOrderEventsByUID(ctx, UID, afterID, eventLogPageSize)
+}
+
+// runEventLogCleanup periodically removes events older than the retention
+// period, streams can't be resumed from them anymore.
+func (g *Gophermart) runEventLogCleanup(ctx context.Context) {
+	retention := config.Get().OrderEventsRetention
+	go func() {
+		ticker := time.NewTicker(eventLogCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := g.Storage.DeleteOrderEventsBefore(ctx, time.Now().Add(-retention)); err != nil {
+					zap.L().Warn("order events: delete old events", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -2,24 +2,42 @@ package gophermart2
 
 import (
 	"context"
-	"time"
+	"errors"
 
 	"github.com/stsg/gophermart2/internal/accrual"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/events"
+	"github.com/stsg/gophermart2/internal/idempotency"
+	"github.com/stsg/gophermart2/internal/lockout"
+	"github.com/stsg/gophermart2/internal/logger"
+	"github.com/stsg/gophermart2/internal/models"
 	"github.com/stsg/gophermart2/internal/storages"
-	"github.com/jmoiron/sqlx"
+	"github.com/stsg/gophermart2/internal/webhooks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-const jobInterval = 10 * time.Second
+var tracer = otel.Tracer("github.com/stsg/gophermart2/internal/services/gophermart")
 
 type Gophermart struct {
 	AccrualClient accrual.Client
 	Storage       storages.Storager
+	LoginLockout  *lockout.Lockout
+	Idempotency   *idempotency.Keys
+	Events        *events.Hub
+
+	accrualBackoff backoff
+	webhooks       *webhooks.Dispatcher
+	pollerWorkers  int
+	poller         *poller
 }
 
 func New(ctx context.Context, opts ...Option) *Gophermart {
 	g := &Gophermart{
 		AccrualClient: accrual.New(),
+		pollerWorkers: config.Get().AccrualPollWorkers,
 	}
 
 	for _, opt := range opts {
@@ -29,64 +47,84 @@ func New(ctx context.Context, opts ...Option) *Gophermart {
 	if g.Storage == nil {
 		WithDefaultStorage(ctx)(g)
 	}
+	g.Storage = storages.WithMetrics(g.Storage)
+	g.LoginLockout = lockout.New(g.Storage)
+	g.Idempotency = idempotency.New(g.Storage)
+	g.Idempotency.RunCleanup(ctx)
+	g.Events = events.Get()
+	g.runEventLogCleanup(ctx)
+	g.webhooks = webhooks.New(g.Storage)
+	g.webhooks.Run(ctx)
+	g.runWebhookDeliveryCleanup(ctx)
+	g.runMetricsUpdate(ctx)
 
-	g.updateOrdersBackground(ctx)
+	g.poller = newPoller(g, g.pollerWorkers)
+	g.poller.run(ctx)
 	return g
 }
 
-func (g *Gophermart) updateOrdersBackground(ctx context.Context) {
-	ticker := time.NewTicker(jobInterval)
-
-	go func() {
-		defer func() {
-			if p := recover(); p != nil {
-				zap.L().Warn("recovered from panic", zap.Any("panic", p))
-			}
-		}()
-
-		for {
-			select {
-			case <-ticker.C:
-				g.updateOrders(ctx)
-			case <-ctx.Done():
-				ticker.Stop()
-				return
-			}
-		}
-	}()
-}
+// updateOrder fetches the order state from the accrual system and stores it.
+// Errors are logged here, the caller only needs them to tune the polling.
+func (g *Gophermart) updateOrder(ctx context.Context, current models.Order) error {
+	ctx, span := tracer.Start(ctx, "gophermart.updateOrder", trace.WithAttributes(attribute.String("order", current.ID)))
+	defer span.End()
 
-func (g *Gophermart) updateOrders(ctx context.Context) {
-	orders, err := g.Storage.GetUnfinishedOrders(ctx)
+	order, err := g.AccrualClient.GetOrderInfo(ctx, current)
 	if err != nil {
-		zap.L().Warn("update orders: get unfinished orders", zap.Error(err))
-		return
+		var tooManyRequests *accrual.TooManyRequestsError
+		switch {
+		case errors.Is(err, accrual.ErrOrderNotRegistered):
+			return nil
+		case errors.As(err, &tooManyRequests):
+			g.accrualBackoff.pause(tooManyRequests.RetryAfter)
+			logger.FromContext(ctx).Warn("update orders: accrual polling paused", zap.Duration("retry_after", tooManyRequests.RetryAfter))
+		default:
+			logger.FromContext(ctx).Warn("update orders: get order info", zap.String("order", current.ID), zap.Error(err))
+		}
+		return err
 	}
 
-	if len(orders) == 0 {
-		return
+	if order.AccrualStatus == current.AccrualStatus {
+		return nil
 	}
 
-	for _, order := range orders {
-		order, err = g.AccrualClient.GetOrderInfo(order)
-		if err != nil {
-			zap.L().Warn("update orders: get order info", zap.Error(err))
-			return
+	var event models.OrderEvent
+	if err = g.Storage.Transaction(ctx, func(ctx context.Context) (err error) {
+		if err = g.Storage.UpdateOrder(ctx, order); err != nil {
+			return err
 		}
-
-		if err = g.Storage.Transaction(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
-			if err := g.Storage.UpdateOrder(ctx, order, tx); err != nil {
-				return err
-			}
-			if order.Accrual == nil {
-				return nil
-			}
-			if err := g.Storage.AddOrIncrBalance(ctx, order.UID, order.Accrual, tx); err != nil {
-				return err
-			}
+		if event, err = g.Storage.AddOrderEvent(ctx, models.NewOrderEvent(order)); err != nil {
+			return err
+		}
+		if err = g.enqueueWebhookEvent(ctx, order.UID, models.WebhookEventOrderUpdated, event); err != nil {
+			return err
+		}
+		if order.AccrualStatus != models.AccrualStatusProcessed || order.Accrual == nil || !order.Accrual.IsPositive() {
 			return nil
-		}); err != nil {
-			zap.L().Warn("update orders: exec transaction error", zap.Error(err))
 		}
+
+		added, err := g.Storage.AddLedgerEntry(ctx, models.LedgerEntry{
+			UID:     order.UID,
+			Kind:    models.LedgerEntryCredit,
+			OrderID: order.ID,
+			Amount:  *order.Accrual,
+		})
+		if err != nil {
+			return err
+		}
+		if !added {
+			logger.FromContext(ctx).Warn("update orders: order already credited", zap.String("order", current.ID))
+		}
+		return g.Storage.ReconcileBalanceByUID(ctx, order.UID)
+	}); err != nil {
+		logger.FromContext(ctx).Warn("update orders: exec transaction error", zap.String("order", current.ID), zap.Error(err))
+		return err
 	}
+	g.Events.Publish(event)
+	return nil
+}
+
+func isRateLimited(err error) bool {
+	var tooManyRequests *accrual.TooManyRequestsError
+	return errors.As(err, &tooManyRequests)
 }
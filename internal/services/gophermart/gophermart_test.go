@@ -0,0 +1,37 @@
+package gophermart2
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stsg/gophermart2/internal/luhn"
+	"github.com/stsg/gophermart2/internal/test/mocks"
+)
+
+// newMockGophermart returns the service over a storage mock whose
+// transactions just run the given function.
+func newMockGophermart(t *testing.T) (*Gophermart, *mocks.MockStorager) {
+	s := mocks.NewMockStorager(gomock.NewController(t))
+	s.EXPECT().Transaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, f func(ctx context.Context) error) error {
+			return f(ctx)
+		}).AnyTimes()
+	return &Gophermart{Storage: s}, s
+}
+
+// luhnNumber returns a unique order number with a valid check digit.
+func luhnNumber(t *testing.T, n int) string {
+	t.Helper()
+	prefix := fmt.Sprintf("%d%04d", time.Now().UnixNano()%1e12, n)
+	for digit := 0; digit < 10; digit++ {
+		if s := prefix + strconv.Itoa(digit); luhn.Valid(s) {
+			return s
+		}
+	}
+	t.Fatalf("no check digit for %s", prefix)
+	return ""
+}
@@ -0,0 +1,79 @@
+package gophermart2
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+var (
+	errShuttingDown = errors.New("shutting down")
+	errPollerStale  = errors.New("accrual poller heartbeat is stale")
+)
+
+// CheckReadiness runs the dependency checks in parallel. It fails right away
+// once the graceful shutdown started, so load balancers stop sending requests.
+func (g *Gophermart) CheckReadiness(ctx context.Context) models.Readiness {
+	if shutdowner.Get().ShuttingDown() {
+		return models.Readiness{
+			Status: models.HealthStatusFail,
+			Checks: map[string]models.HealthCheck{"shutdown": models.NewHealthCheck(errShuttingDown)},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]func(ctx context.Context) error{
+		"database":   g.Storage.Ping,
+		"migrations": g.Storage.CheckMigrations,
+		"accrual":    g.AccrualClient.Ping,
+		"poller": func(context.Context) error {
+			if !g.poller.alive() {
+				return errPollerStale
+			}
+			return nil
+		},
+	}
+
+	type result struct {
+		name  string
+		check models.HealthCheck
+	}
+	// buffered, so the checks ignoring ctx do not leak blocked goroutines
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check func(ctx context.Context) error) {
+			results <- result{name: name, check: models.NewHealthCheck(check(ctx))}
+		}(name, check)
+	}
+
+	res := models.Readiness{
+		Status: models.HealthStatusOK,
+		Checks: make(map[string]models.HealthCheck, len(checks)),
+	}
+	for len(res.Checks) < len(checks) {
+		select {
+		case r := <-results:
+			res.Checks[r.name] = r.check
+		case <-ctx.Done():
+			// report the checks that did not return in time as failed
+			for name := range checks {
+				if _, ok := res.Checks[name]; !ok {
+					res.Checks[name] = models.NewHealthCheck(ctx.Err())
+				}
+			}
+		}
+	}
+	for _, check := range res.Checks {
+		if check.Status != models.HealthStatusOK {
+			res.Status = models.HealthStatusFail
+		}
+	}
+	return res
+}
@@ -0,0 +1,92 @@
+package gophermart2
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter bounds the number of concurrent accrual requests. The limit is
+// halved each time the accrual system reports a rate limit and grows back by
+// one after every successful request, up to max.
+type limiter struct {
+	mu       sync.Mutex
+	limit    int
+	max      int
+	inFlight int
+	changed  chan struct{}
+}
+
+func newLimiter(max int) *limiter {
+	if max < 1 {
+		max = 1
+	}
+	return &limiter{
+		limit:   max,
+		max:     max,
+		changed: make(chan struct{}),
+	}
+}
+
+// acquire blocks until a slot is free or ctx is done.
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.notify()
+}
+
+func (l *limiter) decrease() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit /= 2; l.limit < 1 {
+		l.limit = 1
+	}
+}
+
+func (l *limiter) increase() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limit < l.max {
+		l.limit++
+		l.notify()
+	}
+}
+
+// setMax changes the upper bound, e.g. after a config reload.
+func (l *limiter) setMax(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max < 1 {
+		max = 1
+	}
+	l.max = max
+	if l.limit > max {
+		l.limit = max
+	}
+	l.notify()
+}
+
+// notify wakes up everyone waiting in acquire. Must be called with mu held.
+func (l *limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
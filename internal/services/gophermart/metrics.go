@@ -0,0 +1,38 @@
+package gophermart2
+
+import (
+	"context"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/metrics"
+	"go.uber.org/zap"
+)
+
+const metricsUpdateInterval = 30 * time.Second
+
+// runMetricsUpdate periodically refreshes the metrics calculated from the
+// stored data, so scrapes never wait for the database.
+func (g *Gophermart) runMetricsUpdate(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(metricsUpdateInterval)
+		defer ticker.Stop()
+
+		for {
+			g.updateMetrics(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (g *Gophermart) updateMetrics(ctx context.Context) {
+	total, err := g.Storage.GetTotalBalance(ctx)
+	if err != nil {
+		zap.L().Warn("metrics: get total balance", zap.Error(err))
+		return
+	}
+	metrics.PointsOutstanding.Set(total.Float64())
+}
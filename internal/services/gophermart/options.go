@@ -3,7 +3,10 @@ package gophermart2
 import (
 	"context"
 
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/storages/memory"
 	"github.com/stsg/gophermart2/internal/storages/postgres"
+	"go.uber.org/zap"
 )
 
 type Option func(g *Gophermart)
@@ -14,6 +17,29 @@ func WithPostgreStorage(ctx context.Context) Option {
 	}
 }
 
+// WithMemoryStorage keeps all data in process memory, for tests and local development.
+func WithMemoryStorage() Option {
+	return func(g *Gophermart) {
+		g.Storage = memory.New()
+	}
+}
+
+// WithDefaultStorage selects the storage by config.StorageType.
 func WithDefaultStorage(ctx context.Context) Option {
-	return WithPostgreStorage(ctx)
+	switch config.Get().StorageType {
+	case "memory":
+		return WithMemoryStorage()
+	case "postgres":
+		return WithPostgreStorage(ctx)
+	default:
+		zap.L().Fatal("unknown storage type", zap.String("type", config.Get().StorageType))
+		return nil
+	}
+}
+
+// WithPollerWorkers sets the number of workers updating orders from the accrual system.
+func WithPollerWorkers(workers int) Option {
+	return func(g *Gophermart) {
+		g.pollerWorkers = workers
+	}
 }
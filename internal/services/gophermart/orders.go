@@ -0,0 +1,73 @@
+package gophermart2
+
+import (
+	"context"
+
+	"github.com/stsg/gophermart2/internal/luhn"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// RegisterOrder accepts the order number uploaded by the user for accrual.
+// It returns models.ErrOrderAlreadyExists if the user has already uploaded it.
+func (g *Gophermart) RegisterOrder(ctx context.Context, UID string, number string) error {
+	if !luhn.Valid(number) {
+		return models.ErrInvalidOrderNumber
+	}
+
+	order := models.Order{
+		ID:            number,
+		UID:           UID,
+		AccrualStatus: models.AccrualStatusNew,
+	}
+	return g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		if err := g.Storage.AddOrder(ctx, order); err != nil {
+			return err
+		}
+		return g.Storage.ReconcileBalanceByUID(ctx, UID)
+	})
+}
+
+// GetOrder returns the user order. With refresh the order is updated from the
+// accrual system first, unless it is final or the accrual polling is paused.
+// Orders of other users are reported as models.ErrOrderNotFound.
+func (g *Gophermart) GetOrder(ctx context.Context, UID string, number string, refresh bool) (models.Order, error) {
+	if !luhn.Valid(number) {
+		return models.Order{}, models.ErrInvalidOrderNumber
+	}
+
+	order, err := g.Storage.GetOrderByID(ctx, number)
+	if err != nil {
+		return order, err
+	}
+	if order.UID != UID {
+		return models.Order{}, models.ErrOrderNotFound
+	}
+
+	if !refresh || order.AccrualStatus.IsFinal() || g.accrualBackoff.remaining() > 0 {
+		return order, nil
+	}
+	// the stored order is still a valid answer if the accrual system fails
+	if err = g.updateOrder(ctx, order); err != nil {
+		return order, nil
+	}
+	return g.Storage.GetOrderByID(ctx, number)
+}
+
+// ListOrders returns a page of the user orders and the cursor of the next
+// page, nil if this page is the last one.
+func (g *Gophermart) ListOrders(ctx context.Context, UID string, filter models.OrderFilter) (orders []models.Order, next *models.Cursor, err error) {
+	limit := filter.Limit
+	if limit > 0 {
+		filter.Limit++
+	}
+	if orders, err = g.Storage.GetOrdersByUID(ctx, UID, filter); err != nil {
+		return
+	}
+
+	if limit > 0 && len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[limit-1]
+		next = &models.Cursor{Time: last.UploadedAt, ID: last.ID}
+	}
+	return
+}
@@ -0,0 +1,104 @@
+package gophermart2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/test/mocks"
+)
+
+func TestRegisterOrder(t *testing.T) {
+	number := luhnNumber(t, 1)
+	order := models.Order{ID: number, UID: "user", AccrualStatus: models.AccrualStatusNew}
+
+	tests := []struct {
+		name   string
+		number string
+		expect func(s *mocks.MockStorager)
+		want   error
+	}{
+		{
+			name:   "new order",
+			number: number,
+			expect: func(s *mocks.MockStorager) {
+				s.EXPECT().AddOrder(gomock.Any(), order).Return(nil)
+				s.EXPECT().ReconcileBalanceByUID(gomock.Any(), "user").Return(nil)
+			},
+		},
+		{
+			name:   "duplicate",
+			number: number,
+			expect: func(s *mocks.MockStorager) {
+				s.EXPECT().AddOrder(gomock.Any(), order).Return(models.ErrOrderAlreadyExists)
+			},
+			want: models.ErrOrderAlreadyExists,
+		},
+		{
+			name:   "other user's order",
+			number: number,
+			expect: func(s *mocks.MockStorager) {
+				s.EXPECT().AddOrder(gomock.Any(), order).Return(models.ErrOrderBelongsAnotherUser)
+			},
+			want: models.ErrOrderBelongsAnotherUser,
+		},
+		{
+			name:   "bad Luhn",
+			number: "79927398710",
+			expect: func(s *mocks.MockStorager) {},
+			want:   models.ErrInvalidOrderNumber,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			g, s := newMockGophermart(t)
+			tt.expect(s)
+			if err := g.RegisterOrder(context.Background(), "user", tt.number); !errors.Is(err, tt.want) {
+				t.Fatalf("RegisterOrder = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOrdersPaging(t *testing.T) {
+	g, s := newMockGophermart(t)
+	ctx := context.Background()
+	now := time.Now()
+	statuses := []models.AccrualStatus{models.AccrualStatusNew}
+	orders := []models.Order{
+		{ID: "1", UID: "user", UploadedAt: now},
+		{ID: "2", UID: "user", UploadedAt: now.Add(time.Second)},
+		{ID: "3", UID: "user", UploadedAt: now.Add(2 * time.Second)},
+	}
+
+	// one extra item is asked for to tell whether a next page exists
+	s.EXPECT().GetOrdersByUID(gomock.Any(), "user", models.OrderFilter{Page: models.Page{Limit: 3}, Statuses: statuses}).Return(orders, nil)
+	page, next, err := g.ListOrders(ctx, "user", models.OrderFilter{Page: models.Page{Limit: 2}, Statuses: statuses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[1].ID != "2" {
+		t.Fatalf("page = %+v", page)
+	}
+	if next == nil || next.ID != "2" || !next.Time.Equal(orders[1].UploadedAt) {
+		t.Fatalf("next = %+v, want the cursor of the second order", next)
+	}
+
+	s.EXPECT().GetOrdersByUID(gomock.Any(), "user", models.OrderFilter{Page: models.Page{Limit: 3, After: next}, Statuses: statuses}).Return(orders[2:], nil)
+	page, next, err = g.ListOrders(ctx, "user", models.OrderFilter{Page: models.Page{Limit: 2, After: next}, Statuses: statuses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != "3" || next != nil {
+		t.Fatalf("last page = %+v, next = %+v", page, next)
+	}
+
+	s.EXPECT().GetOrdersByUID(gomock.Any(), "user", models.OrderFilter{}).Return(orders, nil)
+	if page, next, err = g.ListOrders(ctx, "user", models.OrderFilter{}); err != nil || len(page) != 3 || next != nil {
+		t.Fatalf("unlimited list = %+v, %+v, %v", page, next, err)
+	}
+}
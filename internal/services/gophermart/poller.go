@@ -0,0 +1,303 @@
+package gophermart2
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/metrics"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"go.uber.org/zap"
+)
+
+const (
+	// pollerHeartbeatTicks is how many ticks may be missed before the poller is considered dead.
+	pollerHeartbeatTicks = 3
+
+	defaultPollerWorkers = 4
+	pollerQueueFactor    = 16
+)
+
+// poller periodically queues unfinished orders and updates them from the
+// accrual system with a pool of workers. An order is never queued twice while
+// it is waiting or in flight. The interval and the number of workers follow
+// config reloads.
+type poller struct {
+	g       *Gophermart
+	queue   chan models.Order
+	limiter *limiter
+
+	// interval is the tick period in nanoseconds, intervalChanged resets the ticker.
+	interval        int64
+	intervalChanged chan time.Duration
+
+	mu     sync.Mutex
+	queued map[string]struct{}
+	// workerStops holds a stop channel per running worker.
+	workerStops []chan struct{}
+
+	// heartbeat is the Unix time in nanoseconds of the last scheduler loop.
+	heartbeat int64
+
+	stopped context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func newPoller(g *Gophermart, workers int) *poller {
+	if workers < 1 {
+		workers = defaultPollerWorkers
+	}
+	return &poller{
+		g:               g,
+		workerStops:     make([]chan struct{}, 0, workers),
+		interval:        int64(config.Get().AccrualPollInterval),
+		intervalChanged: make(chan time.Duration, 1),
+		queue:           make(chan models.Order, workers*pollerQueueFactor),
+		limiter:         newLimiter(workers),
+		queued:          make(map[string]struct{}),
+	}
+}
+
+// run starts the scheduler and the workers. Updates already in flight keep
+// using ctx, so they are not interrupted when the poller is stopped.
+func (p *poller) run(ctx context.Context) {
+	p.stopped, p.stop = context.WithCancel(ctx)
+	p.beat()
+
+	p.wg.Add(1)
+	go p.schedule()
+	p.mu.Lock()
+	p.startWorkers(ctx, cap(p.workerStops))
+	p.mu.Unlock()
+
+	unsubscribe := config.Subscribe(func(prev, cur *config.Config) {
+		if cur.AccrualPollInterval != prev.AccrualPollInterval {
+			p.setInterval(cur.AccrualPollInterval)
+		}
+		if cur.AccrualPollWorkers != prev.AccrualPollWorkers {
+			p.setWorkers(ctx, cur.AccrualPollWorkers)
+		}
+	})
+	p.addToShutdowner(unsubscribe)
+}
+
+// startWorkers must be called with mu held.
+func (p *poller) startWorkers(ctx context.Context, n int) {
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		stop := make(chan struct{})
+		p.workerStops = append(p.workerStops, stop)
+		go p.work(ctx, stop)
+	}
+}
+
+// stopWorkers stops the last n workers once they finish the current order.
+// Must be called with mu held.
+func (p *poller) stopWorkers(n int) {
+	last := len(p.workerStops) - n
+	for _, stop := range p.workerStops[last:] {
+		close(stop)
+	}
+	p.workerStops = p.workerStops[:last]
+}
+
+func (p *poller) setInterval(d time.Duration) {
+	atomic.StoreInt64(&p.interval, int64(d))
+	// keep only the latest change if the scheduler hasn't picked up the previous one
+	select {
+	case <-p.intervalChanged:
+	default:
+	}
+	p.intervalChanged <- d
+}
+
+// setWorkers starts or stops workers to run exactly n of them.
+func (p *poller) setWorkers(ctx context.Context, n int) {
+	if n < 1 {
+		n = defaultPollerWorkers
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped.Err() != nil {
+		return
+	}
+	switch workers := len(p.workerStops); {
+	case n > workers:
+		p.startWorkers(ctx, n-workers)
+	case n < workers:
+		p.stopWorkers(workers - n)
+	}
+	p.limiter.setMax(n)
+}
+
+func (p *poller) schedule() {
+	defer p.wg.Done()
+	defer recoverPanic()
+
+	ticker := time.NewTicker(time.Duration(atomic.LoadInt64(&p.interval)))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case d := <-p.intervalChanged:
+			ticker.Reset(d)
+		case <-ticker.C:
+			p.enqueueUnfinished()
+		case <-p.stopped.Done():
+			return
+		}
+		p.beat()
+	}
+}
+
+func (p *poller) beat() {
+	atomic.StoreInt64(&p.heartbeat, time.Now().UnixNano())
+}
+
+// alive reports whether the scheduler looped recently. It stops if it has
+// been stopped or died of a panic. A full queue or an accrual backoff don't
+// stop it, enqueueUnfinished never blocks.
+func (p *poller) alive() bool {
+	last := time.Unix(0, atomic.LoadInt64(&p.heartbeat))
+	return time.Since(last) < pollerHeartbeatTicks*time.Duration(atomic.LoadInt64(&p.interval))
+}
+
+func (p *poller) enqueueUnfinished() {
+	if d := p.g.accrualBackoff.remaining(); d > 0 {
+		zap.L().Debug("update orders: accrual polling paused", zap.Duration("remaining", d))
+		return
+	}
+
+	orders, err := p.g.Storage.GetUnfinishedOrders(p.stopped)
+	if err != nil {
+		zap.L().Warn("update orders: get unfinished orders", zap.Error(err))
+		return
+	}
+	metrics.AccrualUnfinishedOrders.Set(float64(len(orders)))
+
+	// orders left out when the queue is full or the accrual system asks to
+	// slow down are queued on one of the next ticks
+	for _, order := range orders {
+		if p.g.accrualBackoff.remaining() > 0 {
+			return
+		}
+		if !p.markQueued(order.ID) {
+			continue
+		}
+		select {
+		case p.queue <- order:
+		default:
+			p.unmarkQueued(order.ID)
+			return
+		}
+	}
+}
+
+func (p *poller) work(ctx context.Context, stop <-chan struct{}) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case order := <-p.queue:
+			p.process(ctx, order)
+		case <-stop:
+			return
+		case <-p.stopped.Done():
+			return
+		}
+	}
+}
+
+func (p *poller) process(ctx context.Context, order models.Order) {
+	defer p.unmarkQueued(order.ID)
+	defer recoverPanic()
+
+	if !p.waitBackoff() {
+		return
+	}
+	if err := p.limiter.acquire(p.stopped); err != nil {
+		return
+	}
+	defer p.limiter.release()
+
+	if err := p.g.updateOrder(ctx, order); err != nil {
+		if isRateLimited(err) {
+			p.limiter.decrease()
+		}
+		return
+	}
+	p.limiter.increase()
+}
+
+// waitBackoff sleeps through the shared accrual backoff window. It reports
+// false if the poller was stopped meanwhile.
+func (p *poller) waitBackoff() bool {
+	for {
+		d := p.g.accrualBackoff.remaining()
+		if d == 0 {
+			return true
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-p.stopped.Done():
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+func (p *poller) markQueued(orderID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.queued[orderID]; ok {
+		return false
+	}
+	p.queued[orderID] = struct{}{}
+	metrics.AccrualQueuedOrders.Inc()
+	return true
+}
+
+func (p *poller) unmarkQueued(orderID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.queued, orderID)
+	metrics.AccrualQueuedOrders.Dec()
+}
+
+// addToShutdowner stops taking new orders and following config reloads on
+// shutdown and waits for in-flight updates to finish.
+func (p *poller) addToShutdowner(unsubscribe func()) {
+	shutdowner.Get().AddCloser(func(ctx context.Context) error {
+		unsubscribe()
+		// under mu, so setWorkers can't start workers after the stop
+		p.mu.Lock()
+		p.stop()
+		p.mu.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			p.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func recoverPanic() {
+	if p := recover(); p != nil {
+		zap.L().Warn("recovered from panic", zap.Any("panic", p))
+	}
+}
@@ -0,0 +1,109 @@
+package gophermart2
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+)
+
+func TestPollerAliveWithFullQueue(t *testing.T) {
+	config.Set(config.Defaults())
+	ctx := context.Background()
+	s := memory.New()
+	g := &Gophermart{Storage: s}
+
+	p := newPoller(g, 1)
+	atomic.StoreInt64(&p.interval, int64(10*time.Millisecond))
+	p.stopped, p.stop = context.WithCancel(ctx)
+	defer p.stop()
+
+	for i := 0; i < 2*cap(p.queue); i++ {
+		order := models.Order{ID: luhnNumber(t, i), UID: "user", AccrualStatus: models.AccrualStatusNew}
+		if err := s.AddOrder(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// no worker takes the orders, as if they all waited for the backoff
+	done := make(chan struct{})
+	go func() {
+		p.enqueueUnfinished()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("enqueueUnfinished blocked on the full queue")
+	}
+	if len(p.queue) != cap(p.queue) {
+		t.Fatalf("%d orders queued, want a full queue of %d", len(p.queue), cap(p.queue))
+	}
+
+	g.accrualBackoff.pause(time.Minute)
+	p.beat()
+	p.wg.Add(1)
+	go p.schedule()
+
+	time.Sleep(2 * pollerHeartbeatTicks * 10 * time.Millisecond)
+	if !p.alive() {
+		t.Fatal("poller reported dead during the accrual backoff")
+	}
+
+	p.stop()
+	p.wg.Wait()
+	time.Sleep(pollerHeartbeatTicks * 10 * time.Millisecond)
+	if p.alive() {
+		t.Fatal("stopped poller reported alive")
+	}
+}
+
+func TestPollerSetWorkers(t *testing.T) {
+	config.Set(config.Defaults())
+	ctx := context.Background()
+	p := newPoller(&Gophermart{Storage: memory.New()}, 2)
+	p.stopped, p.stop = context.WithCancel(ctx)
+	p.mu.Lock()
+	p.startWorkers(ctx, 2)
+	p.mu.Unlock()
+
+	p.setWorkers(ctx, 5)
+	if len(p.workerStops) != 5 || p.limiter.max != 5 {
+		t.Fatalf("%d workers, limit %d after an increase to 5", len(p.workerStops), p.limiter.max)
+	}
+
+	stops := append([]chan struct{}(nil), p.workerStops...)
+	p.setWorkers(ctx, 1)
+	if len(p.workerStops) != 1 || p.limiter.max != 1 {
+		t.Fatalf("%d workers, limit %d after a decrease to 1", len(p.workerStops), p.limiter.max)
+	}
+	for i, stop := range stops {
+		select {
+		case <-stop:
+			if i == 0 {
+				t.Fatal("remaining worker stopped")
+			}
+		default:
+			if i > 0 {
+				t.Fatalf("worker %d not stopped", i)
+			}
+		}
+	}
+
+	// every worker exits once the poller is stopped
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	p.stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("workers didn't exit")
+	}
+}
@@ -0,0 +1,93 @@
+package gophermart2
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/auth"
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+// issueTokens starts a new refresh token family for the user.
+func (g *Gophermart) issueTokens(ctx context.Context, user models.User) (models.TokenPair, error) {
+	pair, refresh, err := auth.NewTokenPair(user, uuid.NewString())
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	if err = g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		return g.Storage.AddRefreshToken(ctx, refresh)
+	}); err != nil {
+		return models.TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// RefreshTokens rotates the refresh token. Presenting an already rotated or
+// revoked token is treated as theft and revokes the whole token family.
+func (g *Gophermart) RefreshTokens(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	if refreshToken == "" {
+		return models.TokenPair{}, models.ErrInvalidRefreshToken
+	}
+
+	var (
+		pair   models.TokenPair
+		reused bool
+	)
+	err := g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		token, err := g.Storage.GetRefreshTokenByHash(ctx, auth.HashRefreshToken(refreshToken))
+		if err != nil {
+			return err
+		}
+
+		if token.IsRevoked() {
+			reused = true
+			return g.Storage.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+		}
+		if token.IsExpired() {
+			return models.ErrInvalidRefreshToken
+		}
+
+		user, err := g.Storage.GetUserByID(ctx, token.UID)
+		if err != nil {
+			return err
+		}
+
+		var next models.RefreshToken
+		pair, next, err = auth.NewTokenPair(user, token.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err = g.Storage.AddRefreshToken(ctx, next); err != nil {
+			return err
+		}
+		return g.Storage.RevokeRefreshToken(ctx, token.ID, &next.ID)
+	})
+	if err == nil && reused {
+		err = models.ErrRefreshTokenReused
+	}
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// Logout revokes the access token and, if given, the family of the refresh token.
+func (g *Gophermart) Logout(ctx context.Context, claims auth.Claims, refreshToken string) error {
+	if refreshToken != "" {
+		if err := g.Storage.Transaction(ctx, func(ctx context.Context) error {
+			token, err := g.Storage.GetRefreshTokenByHash(ctx, auth.HashRefreshToken(refreshToken))
+			if err != nil {
+				return err
+			}
+			if token.UID != claims.UID {
+				return models.ErrInvalidRefreshToken
+			}
+			return g.Storage.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return g.Storage.AddRevokedToken(ctx, claims.JTI, claims.ExpiresAt)
+}
@@ -0,0 +1,134 @@
+package gophermart2
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stsg/gophermart2/internal/auth"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRefreshTokensReuse(t *testing.T) {
+	g, s := newMockGophermart(t)
+	ctx := context.Background()
+	revokedAt, replacedBy := time.Now(), "next"
+	token := models.RefreshToken{
+		ID:         "token",
+		FamilyID:   "family",
+		UID:        "user",
+		ExpiresAt:  time.Now().Add(time.Hour),
+		RevokedAt:  &revokedAt,
+		ReplacedBy: &replacedBy,
+	}
+
+	s.EXPECT().GetRefreshTokenByHash(gomock.Any(), auth.HashRefreshToken("stolen")).Return(token, nil)
+	s.EXPECT().RevokeRefreshTokenFamily(gomock.Any(), "family").Return(nil)
+
+	if _, err := g.RefreshTokens(ctx, "stolen"); !errors.Is(err, models.ErrRefreshTokenReused) {
+		t.Fatalf("RefreshTokens = %v, want %v", err, models.ErrRefreshTokenReused)
+	}
+}
+
+func TestRefreshTokensRotation(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.SecretToken = strings.Repeat("s", 32)
+	config.Set(cfg)
+
+	g, s := newMockGophermart(t)
+	ctx := context.Background()
+	token := models.RefreshToken{ID: "token", FamilyID: "family", UID: "user", ExpiresAt: time.Now().Add(time.Hour)}
+
+	var next models.RefreshToken
+	s.EXPECT().GetRefreshTokenByHash(gomock.Any(), auth.HashRefreshToken("current")).Return(token, nil)
+	s.EXPECT().GetUserByID(gomock.Any(), "user").Return(models.User{ID: "user", TokenGeneration: 2}, nil)
+	s.EXPECT().AddRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, token models.RefreshToken) error {
+		next = token
+		return nil
+	})
+	s.EXPECT().RevokeRefreshToken(gomock.Any(), "token", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, replacedBy *string) error {
+		if replacedBy == nil || *replacedBy != next.ID {
+			t.Errorf("token replaced by %v, want %q", replacedBy, next.ID)
+		}
+		return nil
+	})
+
+	pair, err := g.RefreshTokens(ctx, "current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.FamilyID != "family" || next.UID != "user" || next.TokenHash != auth.HashRefreshToken(pair.RefreshToken) {
+		t.Fatalf("next token = %+v does not continue the family", next)
+	}
+	claims, err := auth.ParseToken(pair.AccessToken)
+	if err != nil || claims.UID != "user" || claims.Generation != 2 {
+		t.Fatalf("access token claims = %+v, %v, want the current token generation", claims, err)
+	}
+}
+
+func TestRefreshTokensExpired(t *testing.T) {
+	g, s := newMockGophermart(t)
+	token := models.RefreshToken{ID: "token", FamilyID: "family", UID: "user", ExpiresAt: time.Now().Add(-time.Second)}
+
+	s.EXPECT().GetRefreshTokenByHash(gomock.Any(), auth.HashRefreshToken("expired")).Return(token, nil)
+
+	if _, err := g.RefreshTokens(context.Background(), "expired"); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Fatalf("RefreshTokens = %v, want %v", err, models.ErrInvalidRefreshToken)
+	}
+}
+
+// TestChangePasswordRevokesTokens changes the password in the second the old
+// token was issued: the old token is revoked, the returned one is not.
+func TestChangePasswordRevokesTokens(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.SecretToken = strings.Repeat("s", 32)
+	config.Set(cfg)
+
+	ctx := context.Background()
+	g := &Gophermart{Storage: memory.New()}
+	hash, err := bcrypt.GenerateFromPassword([]byte("Zebra7!x"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{ID: "user", Login: "alice", Password: string(hash)}
+	if err = g.Storage.AddUser(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	old, err := g.issueTokens(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := g.ChangePassword(ctx, "user", models.PasswordChange{OldPassword: "Zebra7!x", NewPassword: "Giraffe8?y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked := func(accessToken string) bool {
+		t.Helper()
+		claims, err := auth.ParseToken(accessToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revoked, err := g.Storage.IsTokenRevoked(ctx, claims.JTI, claims.UID, claims.Generation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revoked
+	}
+	if !revoked(old.AccessToken) {
+		t.Fatal("token issued before the password change is not revoked")
+	}
+	if revoked(pair.AccessToken) {
+		t.Fatal("token returned by the password change is revoked")
+	}
+	if _, err = g.RefreshTokens(ctx, old.RefreshToken); !errors.Is(err, models.ErrRefreshTokenReused) {
+		t.Fatalf("refresh with the old token = %v, want %v", err, models.ErrRefreshTokenReused)
+	}
+}
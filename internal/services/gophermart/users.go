@@ -0,0 +1,99 @@
+package gophermart2
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/auth"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/policy"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Register creates the user and signs them in. The credentials are expected
+// to be validated against the registration policy already.
+func (g *Gophermart) Register(ctx context.Context, user models.User) (models.TokenPair, error) {
+	hashedPass, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	user.ID = uuid.NewString()
+	user.Password = string(hashedPass)
+
+	if err = g.Storage.AddUser(ctx, user); err != nil {
+		return models.TokenPair{}, err
+	}
+	return g.issueTokens(ctx, user)
+}
+
+// Login checks the credentials, counting failures per login and per client address.
+func (g *Gophermart) Login(ctx context.Context, user models.User, addr string) (models.TokenPair, error) {
+	if err := g.LoginLockout.Check(ctx, user.Login, addr); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	dbUser, err := g.Storage.GetUserByLogin(ctx, user)
+	if err != nil && !errors.Is(err, models.ErrUserNotFound) {
+		return models.TokenPair{}, err
+	}
+	if err != nil || !auth.Authenticate(dbUser, user) {
+		if err = g.LoginLockout.Fail(ctx, user.Login, addr); err != nil {
+			return models.TokenPair{}, err
+		}
+		return models.TokenPair{}, models.ErrInvalidLoginAttempt
+	}
+
+	if err = g.LoginLockout.Succeed(ctx, user.Login); err != nil {
+		return models.TokenPair{}, err
+	}
+	return g.issueTokens(ctx, dbUser)
+}
+
+// ChangePassword replaces the user password, revokes every issued token
+// and returns a new token pair for the current client.
+func (g *Gophermart) ChangePassword(ctx context.Context, UID string, change models.PasswordChange) (models.TokenPair, error) {
+	dbUser, err := g.Storage.GetUserByID(ctx, UID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if !auth.Authenticate(dbUser, models.User{Password: change.OldPassword}) {
+		return models.TokenPair{}, models.ErrInvalidPassword
+	}
+
+	if err = policy.Get().Validate(&models.User{Login: dbUser.Login, Password: change.NewPassword}); err != nil {
+		return models.TokenPair{}, err
+	}
+
+	hashedPass, err := bcrypt.GenerateFromPassword([]byte(change.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	if err = g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		if err := g.Storage.UpdateUserPassword(ctx, UID, string(hashedPass)); err != nil {
+			return err
+		}
+		if err := g.Storage.RevokeRefreshTokensByUID(ctx, UID); err != nil {
+			return err
+		}
+		// the new tokens are issued for the next token generation
+		dbUser, err = g.Storage.GetUserByID(ctx, UID)
+		return err
+	}); err != nil {
+		return models.TokenPair{}, err
+	}
+	return g.issueTokens(ctx, dbUser)
+}
+
+// DeleteUser closes the account: credentials are anonymised and every token
+// is revoked, while orders, withdrawals and the ledger are retained.
+func (g *Gophermart) DeleteUser(ctx context.Context, UID string) error {
+	return g.Storage.Transaction(ctx, func(ctx context.Context) error {
+		if err := g.Storage.AnonymizeUser(ctx, UID); err != nil {
+			return err
+		}
+		return g.Storage.RevokeRefreshTokensByUID(ctx, UID)
+	})
+}
@@ -0,0 +1,145 @@
+package gophermart2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	webhookSecretBytes             = 32
+	webhookDeliveryCleanupInterval = time.Hour
+)
+
+// AddWebhook registers the endpoint for the user events. A secret is
+// generated unless given, the result is the only place it is returned.
+func (g *Gophermart) AddWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if err := webhook.Validate(); err != nil {
+		return webhook, err
+	}
+
+	webhook.ID = uuid.NewString()
+	if webhook.Secret == "" {
+		secret := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return webhook, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	if err := g.Storage.AddWebhook(ctx, webhook); err != nil {
+		return webhook, err
+	}
+	webhook.CreatedAt = time.Now()
+	return webhook, nil
+}
+
+func (g *Gophermart) ListWebhooks(ctx context.Context, UID string) ([]models.Webhook, error) {
+	webhooks, err := g.Storage.GetWebhooksByUID(ctx, UID)
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, err
+}
+
+func (g *Gophermart) DeleteWebhook(ctx context.Context, UID string, ID string) error {
+	return g.Storage.DeleteWebhook(ctx, UID, ID)
+}
+
+// ListWebhookDeliveries returns the delivery log of the user webhook, newest first.
+func (g *Gophermart) ListWebhookDeliveries(ctx context.Context, UID string, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := g.getWebhook(ctx, UID, webhookID); err != nil {
+		return nil, err
+	}
+	return g.Storage.GetWebhookDeliveriesByWebhookID(ctx, webhookID, limit)
+}
+
+// RetryWebhookDelivery queues the delivery again with a fresh set of
+// attempts, e.g. a dead-lettered one after the endpoint is fixed.
+func (g *Gophermart) RetryWebhookDelivery(ctx context.Context, UID string, webhookID string, deliveryID string) error {
+	if _, err := g.getWebhook(ctx, UID, webhookID); err != nil {
+		return err
+	}
+
+	delivery, err := g.Storage.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.WebhookID != webhookID {
+		return models.ErrWebhookDeliveryNotFound
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	return g.Storage.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// getWebhook reports webhooks of other users as models.ErrWebhookNotFound.
+func (g *Gophermart) getWebhook(ctx context.Context, UID string, ID string) (models.Webhook, error) {
+	webhook, err := g.Storage.GetWebhookByID(ctx, ID)
+	if err != nil {
+		return webhook, err
+	}
+	if webhook.UID != UID {
+		return models.Webhook{}, models.ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// enqueueWebhookEvent adds a delivery of the event for every webhook of the
+// user. Call it in the transaction producing the event, so the event is
+// delivered if and only if the transaction is committed.
+func (g *Gophermart) enqueueWebhookEvent(ctx context.Context, UID string, eventType models.WebhookEventType, data interface{}) error {
+	webhooks, err := g.Storage.GetWebhooksByUID(ctx, UID)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if err = g.Storage.AddWebhookDelivery(ctx, models.WebhookDelivery{
+			ID:        uuid.NewString(),
+			WebhookID: webhook.ID,
+			UID:       UID,
+			EventType: eventType,
+			Payload:   payload,
+			Status:    models.WebhookDeliveryPending,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runWebhookDeliveryCleanup periodically removes finished deliveries older
+// than the retention period from the delivery log.
+func (g *Gophermart) runWebhookDeliveryCleanup(ctx context.Context) {
+	retention := config.Get().WebhookDeliveryRetention
+	go func() {
+		ticker := time.NewTicker(webhookDeliveryCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := g.Storage.DeleteWebhookDeliveriesBefore(ctx, time.Now().Add(-retention)); err != nil {
+					zap.L().Warn("webhooks: delete old deliveries", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
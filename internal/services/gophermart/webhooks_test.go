@@ -0,0 +1,115 @@
+package gophermart2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+	"github.com/stsg/gophermart2/internal/webhooks"
+)
+
+func TestRetryWebhookDelivery(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.WebhookMaxAttempts = 1
+	config.Set(cfg)
+
+	var healthy int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	s := memory.New()
+	g := &Gophermart{Storage: s, webhooks: webhooks.New(s, webhooks.WithHTTPClient(server.Client()))}
+
+	webhook, err := g.AddWebhook(ctx, models.Webhook{UID: "user", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.enqueueWebhookEvent(ctx, "user", models.WebhookEventWithdrawalCreated, models.WithdrawalEvent{OrderID: "79927398713", Amount: 100}); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := s.GetWebhookDeliveriesByWebhookID(ctx, webhook.ID, 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("deliveries = %+v, %v", deliveries, err)
+	}
+	deliveryID := deliveries[0].ID
+
+	g.webhooks.DispatchDue(ctx)
+	if delivery, _ := s.GetWebhookDeliveryByID(ctx, deliveryID); delivery.Status != models.WebhookDeliveryDead {
+		t.Fatalf("delivery = %+v, want dead", delivery)
+	}
+
+	if err = g.RetryWebhookDelivery(ctx, "other", webhook.ID, deliveryID); !errors.Is(err, models.ErrWebhookNotFound) {
+		t.Fatalf("retry of other user's delivery: %v", err)
+	}
+	if err = g.RetryWebhookDelivery(ctx, "user", webhook.ID, uuid.NewString()); !errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+		t.Fatalf("retry of unknown delivery: %v", err)
+	}
+
+	if err = g.RetryWebhookDelivery(ctx, "user", webhook.ID, deliveryID); err != nil {
+		t.Fatal(err)
+	}
+	delivery, err := s.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 0 || delivery.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("delivery = %+v, want pending with fresh attempts", delivery)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	g.webhooks.DispatchDue(ctx)
+	if delivery, _ = s.GetWebhookDeliveryByID(ctx, deliveryID); delivery.Status != models.WebhookDeliveryDelivered || delivery.Attempts != 1 {
+		t.Fatalf("delivery = %+v, want delivered after the retry", delivery)
+	}
+}
+
+func TestWithdrawWebhookEvent(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New()
+	g := &Gophermart{Storage: s}
+
+	if _, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: "user", Kind: models.LedgerEntryCredit, OrderID: luhnNumber(t, 0), Amount: 500}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReconcileBalanceByUID(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	webhook, err := g.AddWebhook(ctx, models.Webhook{UID: "user", URL: "https://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = g.Withdraw(ctx, models.Withdrawal{UID: "user", OrderID: luhnNumber(t, 1), Amount: 200}); err != nil {
+		t.Fatal(err)
+	}
+	withdrawals, _, err := g.ListWithdrawals(ctx, "user", models.WithdrawalFilter{})
+	if err != nil || len(withdrawals) != 1 {
+		t.Fatalf("withdrawals = %+v, %v", withdrawals, err)
+	}
+	deliveries, err := s.GetWebhookDeliveriesByWebhookID(ctx, webhook.ID, 10)
+	if err != nil || len(deliveries) != 1 || deliveries[0].EventType != models.WebhookEventWithdrawalCreated {
+		t.Fatalf("deliveries = %+v, %v", deliveries, err)
+	}
+
+	var event models.WithdrawalEvent
+	if err = json.Unmarshal(deliveries[0].Payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.OrderID != withdrawals[0].OrderID || event.Amount != 200 || !event.ProcessedAt.Equal(withdrawals[0].ProcessedAt) {
+		t.Fatalf("event = %+v, withdrawal = %+v", event, withdrawals[0])
+	}
+}
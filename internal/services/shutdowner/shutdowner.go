@@ -5,14 +5,14 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/stsg/gophermart2/internal/config"
 	"go.uber.org/zap"
 )
 
-const gracefulShutdownDelay = 5 * time.Second
-
 var (
 	onceInstance sync.Once
 	onceShutdown sync.Once
@@ -42,6 +42,7 @@ var (
 // }
 type shutdowner struct {
 	mu           sync.RWMutex
+	shuttingDown int32
 	callbacks    []func(context.Context) error
 	chAllClosed  chan struct{}
 	ChShutdowned chan struct{}
@@ -73,12 +74,22 @@ func (s *shutdowner) AddCloser(fn func(ctx context.Context) error) {
 	s.callbacks = append(s.callbacks, fn)
 }
 
+// ShuttingDown reports whether the graceful shutdown has started, so the
+// readiness probe fails while the closers are still waiting to run.
+func (s *shutdowner) ShuttingDown() bool {
+	return atomic.LoadInt32(&s.shuttingDown) == 1
+}
+
 func (s *shutdowner) gracefulShutdown() {
 	onceShutdown.Do(func() {
+		// let load balancers notice the failing readiness before closing anything
+		atomic.StoreInt32(&s.shuttingDown, 1)
+		time.Sleep(config.Get().ShutdownDrainDelay)
+
 		s.mu.RLock()
 		defer s.mu.RUnlock()
 
-		ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownDelay)
+		ctx, cancel := context.WithTimeout(context.Background(), config.Get().ShutdownTimeout)
 		defer cancel()
 
 		errs := make(chan error, len(s.callbacks))
@@ -94,7 +105,8 @@ func (s *shutdowner) gracefulShutdown() {
 
 func (s *shutdowner) catchSignalsAndShutdown() {
 	chStopSignalReceived := make(chan os.Signal, 1)
-	signal.Notify(chStopSignalReceived, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	// SIGHUP reloads the configuration, see config.ReloadOnSignal
+	signal.Notify(chStopSignalReceived, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	<-chStopSignalReceived
 
@@ -104,7 +116,7 @@ func (s *shutdowner) catchSignalsAndShutdown() {
 	case <-s.chAllClosed:
 		close(s.ChShutdowned)
 		return
-	case <-time.After(2 * gracefulShutdownDelay):
+	case <-time.After(2 * config.Get().ShutdownTimeout):
 		zap.L().Warn("graceful shutdown: no response, exit with error")
 		os.Exit(int(syscall.SIGTERM))
 	}
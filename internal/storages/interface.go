@@ -2,38 +2,122 @@ package storages
 
 import (
 	"context"
+	"time"
 
 	"github.com/stsg/gophermart2/internal/models"
-	"github.com/jmoiron/sqlx"
 )
 
 // Storager is the common interface implemented by all storages.
 type Storager interface {
 	StorageReader
 	StorageWriter
-	Transaction(ctx context.Context, f func(ctx context.Context, tx *sqlx.Tx) error) (err error)
+	// Transaction runs f as a unit of work: storage calls made with the context
+	// passed to f are committed together if f returns nil and rolled back otherwise.
+	Transaction(ctx context.Context, f func(ctx context.Context) error) (err error)
+
+	// Ping checks that the storage is reachable.
+	Ping(ctx context.Context) error
+	// CheckMigrations returns models.ErrMigrationsPending if the schema is
+	// behind the migrations embedded in the binary.
+	CheckMigrations(ctx context.Context) error
 }
 
 type StorageReader interface {
 	GetUserByLogin(ctx context.Context, user models.User) (models.User, error)
+	GetUserByID(ctx context.Context, ID string) (models.User, error)
 
-	GetOrdersByUID(ctx context.Context, UID string) ([]models.Order, error)
+	GetOrderByID(ctx context.Context, ID string) (models.Order, error)
+	// GetOrdersByUID returns up to filter.Limit orders (all if it is zero) in
+	// the page order, models.ErrNoOrders if none match.
+	GetOrdersByUID(ctx context.Context, UID string, filter models.OrderFilter) ([]models.Order, error)
 	GetUnfinishedOrders(ctx context.Context) ([]models.Order, error)
 
+	// GetBalanceByUID and GetCurrentBalanceByUID return models.ErrUserNotFound
+	// if the user has no balance.
 	GetBalanceByUID(ctx context.Context, UID string) (models.Balance, error)
-	GetCurrentBalanceByUID(ctx context.Context, UID string, tx *sqlx.Tx) (float64, error)
+	GetCurrentBalanceByUID(ctx context.Context, UID string) (models.Money, error)
+	// GetTotalBalance returns the sum of the current balances of all users.
+	GetTotalBalance(ctx context.Context) (models.Money, error)
+
+	GetWithdrawalsByUID(ctx context.Context, UID string, filter models.WithdrawalFilter) ([]models.Withdrawal, error)
+
+	// GetRefreshTokenByHash locks the token until the end of the enclosing transaction.
+	GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error)
+	// IsTokenRevoked reports whether the access token is denylisted or was issued
+	// for a token generation the user has revoked (password change, account deletion).
+	IsTokenRevoked(ctx context.Context, JTI string, UID string, generation int64) (bool, error)
+
+	GetLoginAttempt(ctx context.Context, key string) (models.LoginAttempt, error)
+
+	// GetIdempotencyKey returns models.ErrIdempotencyKeyNotFound if the key is
+	// unknown or expired.
+	GetIdempotencyKey(ctx context.Context, UID string, key string) (models.IdempotencyKey, error)
+
+	// GetOrderEventsByUID returns up to limit user events following afterID in the order they happened.
+	GetOrderEventsByUID(ctx context.Context, UID string, afterID int64, limit int) ([]models.OrderEvent, error)
 
-	GetWithdrawalsByUID(ctx context.Context, UID string) ([]models.Withdrawal, error)
+	GetWebhookByID(ctx context.Context, ID string) (models.Webhook, error)
+	GetWebhooksByUID(ctx context.Context, UID string) ([]models.Webhook, error)
+	GetWebhookDeliveryByID(ctx context.Context, ID string) (models.WebhookDelivery, error)
+	// GetWebhookDeliveriesByWebhookID returns up to limit deliveries, newest first.
+	GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error)
 }
 
 type StorageWriter interface {
 	AddUser(ctx context.Context, user models.User) error
+	// UpdateUserPassword also revokes every access token issued before.
+	UpdateUserPassword(ctx context.Context, ID string, password string) error
+	// AnonymizeUser closes the account, keeping its orders and withdrawals for accounting.
+	AnonymizeUser(ctx context.Context, ID string) error
+
+	AddOrder(ctx context.Context, OrderID models.Order) error
+	// UpdateOrder returns models.ErrInvalidStatusTransition if the stored
+	// status can't move to the order status.
+	UpdateOrder(ctx context.Context, order models.Order) error
+
+	// AddLedgerEntry records the user account entry with its counter-entry,
+	// reporting false if an entry of this kind already exists for the order.
+	AddLedgerEntry(ctx context.Context, entry models.LedgerEntry) (bool, error)
+	// ReconcileBalanceByUID recalculates the user balance from the ledger, creating it if needed.
+	ReconcileBalanceByUID(ctx context.Context, UID string) error
+
+	// AddWithdrawal returns the withdrawal as stored, with its processing time.
+	AddWithdrawal(ctx context.Context, wth models.Withdrawal) (models.Withdrawal, error)
+
+	AddRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, ID string, replacedBy *string) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeRefreshTokensByUID(ctx context.Context, UID string) error
+	// AddRevokedToken puts the access token ID on the denylist until it expires.
+	AddRevokedToken(ctx context.Context, JTI string, expiresAt time.Time) error
+
+	// AddLoginFailure counts a failed login, restarting the count if the previous failure is older than window.
+	AddLoginFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempt, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
 
-	AddOrder(ctx context.Context, OrderID models.Order, tx *sqlx.Tx) error
-	UpdateOrder(ctx context.Context, order models.Order, tx *sqlx.Tx) error
+	// AddIdempotencyKey reports false if the user already has this key and it has not expired.
+	AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error)
+	// SaveIdempotencyKeyResponse stores the response and the expiry of a key
+	// still in progress with the same fingerprint.
+	SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, UID string, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
 
-	AddOrIncrBalance(ctx context.Context, UID string, ptrBalance *float64, tx *sqlx.Tx) error
-	IncrBalanceWithdrawnByUID(ctx context.Context, UID string, value float64, tx *sqlx.Tx) error
+	// AddOrderEvent appends the event to the log, returning it with the assigned ID.
+	// The events of a user become visible in the order of their IDs.
+	AddOrderEvent(ctx context.Context, event models.OrderEvent) (models.OrderEvent, error)
+	DeleteOrderEventsBefore(ctx context.Context, before time.Time) error
 
-	AddWithdrawal(ctx context.Context, wth models.Withdrawal, tx *sqlx.Tx) error
+	AddWebhook(ctx context.Context, webhook models.Webhook) error
+	// DeleteWebhook also deletes its deliveries.
+	DeleteWebhook(ctx context.Context, UID string, ID string) error
+	AddWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// ClaimWebhookDeliveries returns up to limit pending deliveries that are due,
+	// postponing them by lease, so concurrent dispatchers don't send them twice.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// DeleteWebhookDeliveriesBefore deletes delivered and dead deliveries
+	// created before the time. Pending deliveries are kept.
+	DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error
 }
@@ -0,0 +1,714 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+)
+
+var (
+	errRefreshTokenAlreadyExists = errors.New("memory storage: refresh token already exists")
+	errNonPositiveLedgerAmount   = errors.New("memory storage: ledger amount must be positive")
+)
+
+type txCtxKey struct{}
+
+// Storage keeps everything in process memory. It is meant for tests and
+// local development.
+//
+// Transactions are serializable: a transaction holds the storage lock for its
+// whole duration and works on a copy of the state, which replaces the
+// committed state only if the closure succeeds. Calls made with the context
+// passed to the closure see the transaction state.
+type Storage struct {
+	mu    sync.RWMutex
+	state *state
+}
+
+var _ storages.Storager = (*Storage)(nil)
+
+func New() storages.Storager {
+	return &Storage{state: newState()}
+}
+
+func (s *Storage) Transaction(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txCtxKey{}).(*state); ok {
+		return f(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	working := s.state.clone()
+	if err = f(context.WithValue(ctx, txCtxKey{}, working)); err != nil {
+		return
+	}
+	s.state = working
+	return
+}
+
+// read runs f against the transaction state of ctx or the committed state.
+func (s *Storage) read(ctx context.Context, f func(st *state) error) error {
+	if st, ok := ctx.Value(txCtxKey{}).(*state); ok {
+		return f(st)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return f(s.state)
+}
+
+// write is read for modifications. Outside a transaction f must not change
+// anything before it is sure to succeed.
+func (s *Storage) write(ctx context.Context, f func(st *state) error) error {
+	if st, ok := ctx.Value(txCtxKey{}).(*state); ok {
+		return f(st)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return f(s.state)
+}
+
+func (s *Storage) AddUser(ctx context.Context, u models.User) error {
+	return s.write(ctx, func(st *state) error {
+		if _, ok := st.userIDsByLogin[u.Login]; ok {
+			return models.ErrUserAlreadyExists
+		}
+		u.CreatedAt = time.Now()
+		st.users[u.ID] = user{User: u}
+		st.userIDsByLogin[u.Login] = u.ID
+		return nil
+	})
+}
+
+func (s *Storage) GetUserByLogin(ctx context.Context, u models.User) (res models.User, err error) {
+	err = s.read(ctx, func(st *state) error {
+		id, ok := st.userIDsByLogin[u.Login]
+		if !ok {
+			return models.ErrUserNotFound
+		}
+		res = st.users[id].User
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, ID string) (res models.User, err error) {
+	err = s.read(ctx, func(st *state) error {
+		u, ok := st.users[ID]
+		if !ok || u.deletedAt != nil {
+			return models.ErrUserNotFound
+		}
+		res = u.User
+		return nil
+	})
+	return
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, ID string, password string) error {
+	return s.write(ctx, func(st *state) error {
+		u, ok := st.users[ID]
+		if !ok || u.deletedAt != nil {
+			return models.ErrUserNotFound
+		}
+		u.Password = password
+		u.TokenGeneration++
+		st.users[ID] = u
+		return nil
+	})
+}
+
+func (s *Storage) AnonymizeUser(ctx context.Context, ID string) error {
+	return s.write(ctx, func(st *state) error {
+		u, ok := st.users[ID]
+		if !ok || u.deletedAt != nil {
+			return models.ErrUserNotFound
+		}
+		delete(st.userIDsByLogin, u.Login)
+
+		now := time.Now()
+		u.Login = "deleted:" + u.ID
+		u.Password = ""
+		u.deletedAt = &now
+		u.TokenGeneration++
+		st.users[ID] = u
+		st.userIDsByLogin[u.Login] = u.ID
+		return nil
+	})
+}
+
+func (s *Storage) GetOrderByID(ctx context.Context, ID string) (order models.Order, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if order, ok = st.orders[ID]; !ok {
+			return models.ErrOrderNotFound
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) AddOrder(ctx context.Context, newOrder models.Order) error {
+	return s.write(ctx, func(st *state) error {
+		if order, ok := st.orders[newOrder.ID]; ok {
+			if order.UID == newOrder.UID {
+				return models.ErrOrderAlreadyExists
+			}
+			return models.ErrOrderBelongsAnotherUser
+		}
+		newOrder.UploadedAt = time.Now()
+		st.orders[newOrder.ID] = newOrder
+		return nil
+	})
+}
+
+func (s *Storage) UpdateOrder(ctx context.Context, order models.Order) error {
+	return s.write(ctx, func(st *state) error {
+		current, ok := st.orders[order.ID]
+		if !ok || !current.AccrualStatus.CanTransitionTo(order.AccrualStatus) {
+			return models.ErrInvalidStatusTransition
+		}
+		current.Accrual = order.Accrual
+		current.AccrualStatus = order.AccrualStatus
+		st.orders[order.ID] = current
+		return nil
+	})
+}
+
+func (s *Storage) GetOrdersByUID(ctx context.Context, UID string, filter models.OrderFilter) (orders []models.Order, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, order := range st.orders {
+			if order.UID == UID && hasStatus(filter.Statuses, order.AccrualStatus) && filter.Includes(order.UploadedAt, order.ID) {
+				orders = append(orders, order)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if len(orders) == 0 {
+		return nil, models.ErrNoOrders
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return filter.Less(orders[i].UploadedAt, orders[i].ID, orders[j].UploadedAt, orders[j].ID)
+	})
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+	return
+}
+
+// Ping always succeeds, the data lives in the process.
+func (s *Storage) Ping(_ context.Context) error {
+	return nil
+}
+
+// CheckMigrations always succeeds, there is no schema to migrate.
+func (s *Storage) CheckMigrations(_ context.Context) error {
+	return nil
+}
+
+func (s *Storage) GetUnfinishedOrders(ctx context.Context) (orders []models.Order, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, order := range st.orders {
+			if !order.AccrualStatus.IsFinal() {
+				orders = append(orders, order)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetBalanceByUID(ctx context.Context, UID string) (balance models.Balance, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if balance, ok = st.balances[UID]; !ok {
+			return models.ErrUserNotFound
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetCurrentBalanceByUID(ctx context.Context, UID string) (models.Money, error) {
+	balance, err := s.GetBalanceByUID(ctx, UID)
+	if err != nil {
+		return -1, err
+	}
+	return balance.Current, nil
+}
+
+func (s *Storage) AddWithdrawal(ctx context.Context, withdrawal models.Withdrawal) (res models.Withdrawal, err error) {
+	err = s.write(ctx, func(st *state) error {
+		if _, ok := st.withdrawals[withdrawal.OrderID]; ok {
+			return models.ErrWithdrawalAlreadyExists
+		}
+		withdrawal.ProcessedAt = time.Now()
+		st.withdrawals[withdrawal.OrderID] = withdrawal
+		res = withdrawal
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetWithdrawalsByUID(ctx context.Context, UID string, filter models.WithdrawalFilter) (withdrawals []models.Withdrawal, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, withdrawal := range st.withdrawals {
+			if withdrawal.UID == UID && filter.Includes(withdrawal.ProcessedAt, withdrawal.OrderID) {
+				withdrawals = append(withdrawals, withdrawal)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if len(withdrawals) == 0 {
+		return nil, models.ErrNoWithdrawals
+	}
+	sort.Slice(withdrawals, func(i, j int) bool {
+		return filter.Less(withdrawals[i].ProcessedAt, withdrawals[i].OrderID, withdrawals[j].ProcessedAt, withdrawals[j].OrderID)
+	})
+	if filter.Limit > 0 && len(withdrawals) > filter.Limit {
+		withdrawals = withdrawals[:filter.Limit]
+	}
+	return
+}
+
+func hasStatus(statuses []models.AccrualStatus, status models.AccrualStatus) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) GetTotalBalance(ctx context.Context) (total models.Money, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, balance := range st.balances {
+			total = total.Add(balance.Current)
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) AddLedgerEntry(ctx context.Context, entry models.LedgerEntry) (added bool, err error) {
+	err = s.write(ctx, func(st *state) error {
+		if !entry.Amount.IsPositive() {
+			return errNonPositiveLedgerAmount
+		}
+		entry.Account = models.LedgerAccountUser
+		key := ledgerKey{account: entry.Account, kind: entry.Kind, orderID: entry.OrderID}
+		if _, ok := st.ledger[key]; ok {
+			return nil
+		}
+		entry.CreatedAt = time.Now()
+		counter := entry.CounterEntry()
+		st.ledger[key] = entry
+		st.ledger[ledgerKey{account: counter.Account, kind: counter.Kind, orderID: counter.OrderID}] = counter
+		added = true
+		return nil
+	})
+	return
+}
+
+func (s *Storage) ReconcileBalanceByUID(ctx context.Context, UID string) error {
+	return s.write(ctx, func(st *state) error {
+		balance := models.Balance{UID: UID}
+		for _, entry := range st.ledger {
+			if entry.UID != UID || entry.Account != models.LedgerAccountUser {
+				continue
+			}
+			switch entry.Kind {
+			case models.LedgerEntryCredit:
+				balance.Current = balance.Current.Add(entry.Amount)
+			case models.LedgerEntryDebit:
+				balance.Current = balance.Current.Sub(entry.Amount)
+				balance.Withdrawn = balance.Withdrawn.Add(entry.Amount)
+			}
+		}
+		// the balances_current_balance_non_negative check of the postgres storage
+		if balance.Current.Less(0) {
+			return models.ErrInsufficientFunds
+		}
+		st.balances[UID] = balance
+		return nil
+	})
+}
+
+func (s *Storage) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	return s.write(ctx, func(st *state) error {
+		if _, ok := st.refreshTokenIDsByHash[token.TokenHash]; ok {
+			return errRefreshTokenAlreadyExists
+		}
+		if _, ok := st.refreshTokens[token.ID]; ok {
+			return errRefreshTokenAlreadyExists
+		}
+		token.CreatedAt = time.Now()
+		st.refreshTokens[token.ID] = token
+		st.refreshTokenIDsByHash[token.TokenHash] = token.ID
+		return nil
+	})
+}
+
+func (s *Storage) GetRefreshTokenByHash(ctx context.Context, hash string) (token models.RefreshToken, err error) {
+	err = s.read(ctx, func(st *state) error {
+		id, ok := st.refreshTokenIDsByHash[hash]
+		if !ok {
+			return models.ErrInvalidRefreshToken
+		}
+		token = st.refreshTokens[id]
+		return nil
+	})
+	return
+}
+
+func (s *Storage) RevokeRefreshToken(ctx context.Context, ID string, replacedBy *string) error {
+	return s.write(ctx, func(st *state) error {
+		token, ok := st.refreshTokens[ID]
+		if !ok || token.IsRevoked() {
+			return nil
+		}
+		now := time.Now()
+		token.RevokedAt = &now
+		token.ReplacedBy = replacedBy
+		st.refreshTokens[ID] = token
+		return nil
+	})
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	return s.revokeRefreshTokens(ctx, func(token models.RefreshToken) bool {
+		return token.FamilyID == familyID
+	})
+}
+
+func (s *Storage) RevokeRefreshTokensByUID(ctx context.Context, UID string) error {
+	return s.revokeRefreshTokens(ctx, func(token models.RefreshToken) bool {
+		return token.UID == UID
+	})
+}
+
+func (s *Storage) revokeRefreshTokens(ctx context.Context, match func(models.RefreshToken) bool) error {
+	return s.write(ctx, func(st *state) error {
+		now := time.Now()
+		for id, token := range st.refreshTokens {
+			if match(token) && !token.IsRevoked() {
+				token.RevokedAt = &now
+				st.refreshTokens[id] = token
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) AddRevokedToken(ctx context.Context, JTI string, expiresAt time.Time) error {
+	return s.write(ctx, func(st *state) error {
+		if _, ok := st.revokedTokens[JTI]; !ok {
+			st.revokedTokens[JTI] = expiresAt
+		}
+		now := time.Now()
+		for jti, exp := range st.revokedTokens {
+			if exp.Before(now) {
+				delete(st.revokedTokens, jti)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, JTI string, UID string, generation int64) (revoked bool, err error) {
+	err = s.read(ctx, func(st *state) error {
+		if _, ok := st.revokedTokens[JTI]; ok {
+			revoked = true
+			return nil
+		}
+		if u, ok := st.users[UID]; ok {
+			revoked = u.deletedAt != nil || generation < u.TokenGeneration
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetLoginAttempt(ctx context.Context, key string) (attempt models.LoginAttempt, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if attempt, ok = st.loginAttempts[key]; !ok {
+			attempt = models.LoginAttempt{Key: key}
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) AddLoginFailure(ctx context.Context, key string, window time.Duration) (attempt models.LoginAttempt, err error) {
+	err = s.write(ctx, func(st *state) error {
+		now := time.Now()
+		var ok bool
+		if attempt, ok = st.loginAttempts[key]; !ok || attempt.UpdatedAt.Before(now.Add(-window)) {
+			attempt.Key = key
+			attempt.Failures = 0
+		}
+		attempt.Failures++
+		attempt.UpdatedAt = now
+		st.loginAttempts[key] = attempt
+		return nil
+	})
+	return
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return s.write(ctx, func(st *state) error {
+		attempt, ok := st.loginAttempts[key]
+		if !ok {
+			return nil
+		}
+		attempt.LockedUntil = &until
+		st.loginAttempts[key] = attempt
+		return nil
+	})
+}
+
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) error {
+	return s.write(ctx, func(st *state) error {
+		delete(st.loginAttempts, key)
+		return nil
+	})
+}
+
+func (s *Storage) AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (added bool, err error) {
+	err = s.write(ctx, func(st *state) error {
+		id := idempotencyKeyID{uid: key.UID, key: key.Key}
+		now := time.Now()
+		if existing, ok := st.idempotencyKeys[id]; ok && existing.ExpiresAt.After(now) {
+			return nil
+		}
+		key.StatusCode, key.ContentType, key.Body = nil, "", nil
+		key.CreatedAt = now
+		st.idempotencyKeys[id] = key
+		added = true
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetIdempotencyKey(ctx context.Context, UID string, key string) (res models.IdempotencyKey, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if res, ok = st.idempotencyKeys[idempotencyKeyID{uid: UID, key: key}]; !ok || !res.ExpiresAt.After(time.Now()) {
+			return models.ErrIdempotencyKeyNotFound
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error {
+	return s.write(ctx, func(st *state) error {
+		id := idempotencyKeyID{uid: key.UID, key: key.Key}
+		existing, ok := st.idempotencyKeys[id]
+		if !ok || existing.Fingerprint != key.Fingerprint || existing.IsCompleted() {
+			return nil
+		}
+		existing.StatusCode, existing.ContentType, existing.Body = key.StatusCode, key.ContentType, key.Body
+		existing.ExpiresAt = key.ExpiresAt
+		st.idempotencyKeys[id] = existing
+		return nil
+	})
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, UID string, key string) error {
+	return s.write(ctx, func(st *state) error {
+		delete(st.idempotencyKeys, idempotencyKeyID{uid: UID, key: key})
+		return nil
+	})
+}
+
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	return s.write(ctx, func(st *state) error {
+		now := time.Now()
+		for id, key := range st.idempotencyKeys {
+			if !key.ExpiresAt.After(now) {
+				delete(st.idempotencyKeys, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) AddOrderEvent(ctx context.Context, event models.OrderEvent) (res models.OrderEvent, err error) {
+	err = s.write(ctx, func(st *state) error {
+		st.lastOrderEventID++
+		event.ID = st.lastOrderEventID
+		event.CreatedAt = time.Now()
+		st.orderEvents = append(st.orderEvents, event)
+		res = event
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetOrderEventsByUID(ctx context.Context, UID string, afterID int64, limit int) (events []models.OrderEvent, err error) {
+	err = s.read(ctx, func(st *state) error {
+		i := sort.Search(len(st.orderEvents), func(i int) bool { return st.orderEvents[i].ID > afterID })
+		for ; i < len(st.orderEvents) && len(events) < limit; i++ {
+			if st.orderEvents[i].UID == UID {
+				events = append(events, st.orderEvents[i])
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) DeleteOrderEventsBefore(ctx context.Context, before time.Time) error {
+	return s.write(ctx, func(st *state) error {
+		i := sort.Search(len(st.orderEvents), func(i int) bool { return !st.orderEvents[i].CreatedAt.Before(before) })
+		st.orderEvents = append([]models.OrderEvent(nil), st.orderEvents[i:]...)
+		return nil
+	})
+}
+
+func (s *Storage) AddWebhook(ctx context.Context, webhook models.Webhook) error {
+	return s.write(ctx, func(st *state) error {
+		webhook.CreatedAt = time.Now()
+		st.webhooks[webhook.ID] = webhook
+		return nil
+	})
+}
+
+func (s *Storage) GetWebhookByID(ctx context.Context, ID string) (webhook models.Webhook, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if webhook, ok = st.webhooks[ID]; !ok {
+			return models.ErrWebhookNotFound
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetWebhooksByUID(ctx context.Context, UID string) (webhooks []models.Webhook, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, webhook := range st.webhooks {
+			if webhook.UID == UID {
+				webhooks = append(webhooks, webhook)
+			}
+		}
+		return nil
+	})
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	return
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, UID string, ID string) error {
+	return s.write(ctx, func(st *state) error {
+		if webhook, ok := st.webhooks[ID]; !ok || webhook.UID != UID {
+			return models.ErrWebhookNotFound
+		}
+		delete(st.webhooks, ID)
+		for id, delivery := range st.webhookDeliveries {
+			if delivery.WebhookID == ID {
+				delete(st.webhookDeliveries, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) AddWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	return s.write(ctx, func(st *state) error {
+		now := time.Now()
+		delivery.CreatedAt = now
+		delivery.NextAttemptAt = now
+		st.webhookDeliveries[delivery.ID] = delivery
+		return nil
+	})
+}
+
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.WebhookDelivery, err error) {
+	err = s.write(ctx, func(st *state) error {
+		now := time.Now()
+		for _, delivery := range st.webhookDeliveries {
+			if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt) })
+		if len(deliveries) > limit {
+			deliveries = deliveries[:limit]
+		}
+		for i := range deliveries {
+			deliveries[i].NextAttemptAt = now.Add(lease)
+			st.webhookDeliveries[deliveries[i].ID] = deliveries[i]
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	return s.write(ctx, func(st *state) error {
+		stored, ok := st.webhookDeliveries[delivery.ID]
+		if !ok {
+			return nil
+		}
+		// like the postgres storage, only the delivery state is updated
+		delivery.WebhookID, delivery.UID, delivery.EventType = stored.WebhookID, stored.UID, stored.EventType
+		delivery.Payload, delivery.CreatedAt = stored.Payload, stored.CreatedAt
+		st.webhookDeliveries[delivery.ID] = delivery
+		return nil
+	})
+}
+
+func (s *Storage) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error {
+	return s.write(ctx, func(st *state) error {
+		for id, delivery := range st.webhookDeliveries {
+			if delivery.Status != models.WebhookDeliveryPending && delivery.CreatedAt.Before(before) {
+				delete(st.webhookDeliveries, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) GetWebhookDeliveryByID(ctx context.Context, ID string) (delivery models.WebhookDelivery, err error) {
+	err = s.read(ctx, func(st *state) error {
+		var ok bool
+		if delivery, ok = st.webhookDeliveries[ID]; !ok {
+			return models.ErrWebhookDeliveryNotFound
+		}
+		return nil
+	})
+	return
+}
+
+func (s *Storage) GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) (deliveries []models.WebhookDelivery, err error) {
+	err = s.read(ctx, func(st *state) error {
+		for _, delivery := range st.webhookDeliveries {
+			if delivery.WebhookID == webhookID {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return
+}
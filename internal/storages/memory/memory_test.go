@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+	"github.com/stsg/gophermart2/internal/storages/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.Storager {
+		return New()
+	})
+}
+
+func TestLedgerCounterEntries(t *testing.T) {
+	ctx := context.Background()
+	s := New().(*Storage)
+	for _, entry := range []models.LedgerEntry{
+		{UID: "user", Kind: models.LedgerEntryCredit, OrderID: "1", Amount: 1000},
+		{UID: "user", Kind: models.LedgerEntryDebit, OrderID: "2", Amount: 400},
+	} {
+		if _, err := s.AddLedgerEntry(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sums := make(map[string]models.Money)
+	for _, entry := range s.state.ledger {
+		if entry.Kind == models.LedgerEntryCredit {
+			sums[entry.OrderID] = sums[entry.OrderID].Add(entry.Amount)
+		} else {
+			sums[entry.OrderID] = sums[entry.OrderID].Sub(entry.Amount)
+		}
+	}
+	if len(s.state.ledger) != 4 {
+		t.Fatalf("got %d ledger entries, want 4", len(s.state.ledger))
+	}
+	for orderID, sum := range sums {
+		if sum != 0 {
+			t.Fatalf("entries of order %s sum to %v", orderID, sum)
+		}
+	}
+}
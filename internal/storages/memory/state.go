@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/stsg/gophermart2/internal/models"
+)
+
+type user struct {
+	models.User
+	deletedAt *time.Time
+}
+
+type ledgerKey struct {
+	account models.LedgerAccount
+	kind    models.LedgerEntryKind
+	orderID string
+}
+
+type idempotencyKeyID struct {
+	uid string
+	key string
+}
+
+// state is the whole content of the storage. Records are stored by value and
+// replaced on update, so a shallow copy of the maps is an independent snapshot.
+type state struct {
+	users          map[string]user
+	userIDsByLogin map[string]string
+
+	orders      map[string]models.Order
+	balances    map[string]models.Balance
+	withdrawals map[string]models.Withdrawal
+	ledger      map[ledgerKey]models.LedgerEntry
+
+	refreshTokens         map[string]models.RefreshToken
+	refreshTokenIDsByHash map[string]string
+	revokedTokens         map[string]time.Time
+
+	loginAttempts map[string]models.LoginAttempt
+
+	idempotencyKeys map[idempotencyKeyID]models.IdempotencyKey
+
+	orderEvents      []models.OrderEvent
+	lastOrderEventID int64
+
+	webhooks          map[string]models.Webhook
+	webhookDeliveries map[string]models.WebhookDelivery
+}
+
+func newState() *state {
+	return &state{
+		users:                 make(map[string]user),
+		userIDsByLogin:        make(map[string]string),
+		orders:                make(map[string]models.Order),
+		balances:              make(map[string]models.Balance),
+		withdrawals:           make(map[string]models.Withdrawal),
+		ledger:                make(map[ledgerKey]models.LedgerEntry),
+		refreshTokens:         make(map[string]models.RefreshToken),
+		refreshTokenIDsByHash: make(map[string]string),
+		revokedTokens:         make(map[string]time.Time),
+		loginAttempts:         make(map[string]models.LoginAttempt),
+		idempotencyKeys:       make(map[idempotencyKeyID]models.IdempotencyKey),
+		webhooks:              make(map[string]models.Webhook),
+		webhookDeliveries:     make(map[string]models.WebhookDelivery),
+	}
+}
+
+func (st *state) clone() *state {
+	return &state{
+		users:                 cloneMap(st.users),
+		userIDsByLogin:        cloneMap(st.userIDsByLogin),
+		orders:                cloneMap(st.orders),
+		balances:              cloneMap(st.balances),
+		withdrawals:           cloneMap(st.withdrawals),
+		ledger:                cloneMap(st.ledger),
+		refreshTokens:         cloneMap(st.refreshTokens),
+		refreshTokenIDsByHash: cloneMap(st.refreshTokenIDsByHash),
+		revokedTokens:         cloneMap(st.revokedTokens),
+		loginAttempts:         cloneMap(st.loginAttempts),
+		idempotencyKeys:       cloneMap(st.idempotencyKeys),
+		// capped, so appending to the copy never writes into the committed log
+		orderEvents:       st.orderEvents[:len(st.orderEvents):len(st.orderEvents)],
+		lastOrderEventID:  st.lastOrderEventID,
+		webhooks:          cloneMap(st.webhooks),
+		webhookDeliveries: cloneMap(st.webhookDeliveries),
+	}
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	res := make(map[K]V, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+package storages
+
+import (
+	"context"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/metrics"
+)
+
+type instrumentedTxCtxKey struct{}
+
+// instrumented observes the transactions of the wrapped storage.
+type instrumented struct {
+	Storager
+}
+
+// WithMetrics wraps the storage to report transaction durations and rollbacks.
+func WithMetrics(s Storager) Storager {
+	return &instrumented{Storager: s}
+}
+
+func (s *instrumented) Transaction(ctx context.Context, f func(ctx context.Context) error) error {
+	// nested transactions are part of the outer one
+	if ctx.Value(instrumentedTxCtxKey{}) != nil {
+		return s.Storager.Transaction(ctx, f)
+	}
+
+	start := time.Now()
+	err := s.Storager.Transaction(context.WithValue(ctx, instrumentedTxCtxKey{}, struct{}{}), f)
+	metrics.DBTransactionDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.DBTransactionRollbacks.Inc()
+	}
+	return err
+}
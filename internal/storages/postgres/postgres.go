@@ -6,19 +6,50 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"github.com/jackc/pgconn"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
 	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/logger"
+	"github.com/stsg/gophermart2/internal/metrics"
 	"github.com/stsg/gophermart2/internal/models"
 	"github.com/stsg/gophermart2/internal/services/shutdowner"
 	"github.com/stsg/gophermart2/internal/storages"
-	_ "github.com/jackc/pgx/v4/stdlib"
-	"github.com/jmoiron/sqlx"
-	"github.com/pressly/goose/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-const queryTimeout = time.Second
+const (
+	txMaxAttempts = 5
+	txRetryDelay  = 10 * time.Millisecond
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation  = "23505"
+	pgCheckViolation   = "23514"
+	pgDeadlockDetected = "40P01"
+)
+
+func hasPgErrorCode(err error, codes ...string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	for _, code := range codes {
+		if pgErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
 
 var (
 	//go:embed sql/migrations/*.sql
@@ -38,24 +69,83 @@ func getQueryFromFile(filename string) (string, error) {
 	return string(query), nil
 }
 
+type txCtxKey struct{}
+
+// querier is implemented by both *sqlx.DB and *sqlx.Tx.
+type querier interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
 type Storage struct {
-	db      *sqlx.DB
-	queries struct {
-		insertOrUpdateBalancesByUID string
-		updateBalanceWithdrawnByUID string
+	db           *sqlx.DB
+	queryTimeout time.Duration
+	// migrationsVersion is the latest embedded migration, set by migrate
+	migrationsVersion int64
+	queries           struct {
+		reconcileBalanceByUID       string
 		selectBalanceByUID          string
+		selectBalanceByUIDForUpdate string
+		selectTotalBalance          string
+
+		insertLedgerEntry string
 
 		insertOrder            string
 		updateOrders           string
 		selectOrderByID        string
 		selectOrdersByUID      string
+		selectOrdersByUIDDesc  string
 		selectOrdersByStatuses string
 
-		insertUser        string
-		selectUserByLogin string
-
-		insertWithdrawals      string
-		selectWithdrawalsByUID string
+		insertUser         string
+		selectUserByLogin  string
+		selectUserByID     string
+		updateUserPassword string
+		anonymizeUser      string
+
+		insertWithdrawals          string
+		selectWithdrawalsByUID     string
+		selectWithdrawalsByUIDDesc string
+
+		insertRefreshToken         string
+		selectRefreshTokenByHash   string
+		revokeRefreshToken         string
+		revokeRefreshTokenFamily   string
+		revokeRefreshTokensByUID   string
+		insertRevokedToken         string
+		deleteExpiredRevokedTokens string
+		selectRevokedTokenExists   string
+
+		selectLoginAttemptByKey string
+		upsertLoginFailure      string
+		updateLoginLock         string
+		deleteLoginAttempt      string
+
+		insertIdempotencyKey         string
+		selectIdempotencyKey         string
+		updateIdempotencyKeyResponse string
+		deleteIdempotencyKey         string
+		deleteExpiredIdempotencyKeys string
+
+		lockOrderEventsByUID    string
+		insertOrderEvent        string
+		selectOrderEventsByUID  string
+		deleteOrderEventsBefore string
+
+		insertWebhook                      string
+		selectWebhookByID                  string
+		selectWebhooksByUID                string
+		deleteWebhook                      string
+		insertWebhookDelivery              string
+		claimWebhookDeliveries             string
+		updateWebhookDelivery              string
+		selectWebhookDeliveryByID          string
+		selectWebhookDeliveriesByWebhookID string
+		deleteWebhookDeliveriesBefore      string
+
+		selectMigrationsVersion string
 	}
 }
 
@@ -67,7 +157,7 @@ func New(ctx context.Context) storages.Storager {
 		zap.L().Fatal("storage constructor: connect to postgres db", zap.Error(err))
 	}
 
-	s := &Storage{db: db}
+	s := &Storage{db: db, queryTimeout: config.Get().DatabaseQueryTimeout}
 	if err = s.migrate(ctx); err != nil {
 		zap.L().Fatal("storage constructor: applies migrations", zap.Error(err))
 	}
@@ -79,9 +169,9 @@ func New(ctx context.Context) storages.Storager {
 }
 
 func (s *Storage) AddUser(ctx context.Context, user models.User) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	ctx, cancel := s.startQuery(ctx, "AddUser")
 	defer cancel()
-	res, err := s.db.NamedExecContext(ctx, s.queries.insertUser, user)
+	res, err := s.conn(ctx).NamedExecContext(ctx, s.queries.insertUser, user)
 	if err != nil {
 		return
 	}
@@ -98,19 +188,76 @@ func (s *Storage) AddUser(ctx context.Context, user models.User) (err error) {
 }
 
 func (s *Storage) GetUserByLogin(ctx context.Context, user models.User) (res models.User, err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	ctx, cancel := s.startQuery(ctx, "GetUserByLogin")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &res, s.queries.selectUserByLogin, user.Login); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, models.ErrUserNotFound
+		}
+		return
+	}
+	return
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, ID string) (res models.User, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetUserByID")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &res, s.queries.selectUserByID, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return res, models.ErrUserNotFound
+		}
+		return
+	}
+	return
+}
+
+func (s *Storage) UpdateUserPassword(ctx context.Context, ID string, password string) error {
+	ctx, cancel := s.startQuery(ctx, "UpdateUserPassword")
+	defer cancel()
+	return s.execAffectingUser(ctx, s.queries.updateUserPassword, ID, password)
+}
+
+func (s *Storage) AnonymizeUser(ctx context.Context, ID string) error {
+	ctx, cancel := s.startQuery(ctx, "AnonymizeUser")
+	defer cancel()
+	return s.execAffectingUser(ctx, s.queries.anonymizeUser, ID)
+}
+
+// execAffectingUser returns models.ErrUserNotFound if the query changed no rows.
+func (s *Storage) execAffectingUser(ctx context.Context, query string, args ...interface{}) error {
+	res, err := s.conn(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	numRowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if numRowsAffected == 0 {
+		return models.ErrUserNotFound
+	}
+	return nil
+}
+
+func (s *Storage) GetOrderByID(ctx context.Context, ID string) (order models.Order, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetOrderByID")
 	defer cancel()
-	if err = s.db.GetContext(ctx, &res, s.queries.selectUserByLogin, user.Login); err != nil {
+	if err = s.conn(ctx).GetContext(ctx, &order, s.queries.selectOrderByID, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order, models.ErrOrderNotFound
+		}
 		return
 	}
 	return
 }
 
-func (s *Storage) AddOrder(ctx context.Context, newOrder models.Order, tx *sqlx.Tx) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) AddOrder(ctx context.Context, newOrder models.Order) (err error) {
+	ctx, cancel := s.startQuery(ctx, "AddOrder")
 	defer cancel()
 	var order models.Order
-	if err = tx.GetContext(ctx, &order, s.queries.selectOrderByID, newOrder.ID); err == nil {
+	if err = s.conn(ctx).GetContext(ctx, &order, s.queries.selectOrderByID, newOrder.ID); err == nil {
 		if order.UID == newOrder.UID {
 			return models.ErrOrderAlreadyExists
 		}
@@ -120,23 +267,44 @@ func (s *Storage) AddOrder(ctx context.Context, newOrder models.Order, tx *sqlx.
 		return
 	}
 
-	ctx, cancel = context.WithTimeout(ctx, queryTimeout)
-	defer cancel()
-	_, err = s.db.NamedExecContext(ctx, s.queries.insertOrder, &newOrder)
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.insertOrder, &newOrder)
 	return
 }
 
-func (s *Storage) UpdateOrder(ctx context.Context, order models.Order, tx *sqlx.Tx) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) UpdateOrder(ctx context.Context, order models.Order) (err error) {
+	ctx, cancel := s.startQuery(ctx, "UpdateOrder")
 	defer cancel()
-	_, err = tx.NamedExecContext(ctx, s.queries.updateOrders, &order)
+	res, err := s.conn(ctx).NamedExecContext(ctx, s.queries.updateOrders, &order)
+	if err != nil {
+		return
+	}
+
+	numRowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	if numRowsAffected == 0 {
+		return models.ErrInvalidStatusTransition
+	}
 	return
 }
 
-func (s *Storage) GetOrdersByUID(ctx context.Context, UID string) (orders []models.Order, err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) GetOrdersByUID(ctx context.Context, UID string, filter models.OrderFilter) (orders []models.Order, err error) {
+	query := s.queries.selectOrdersByUID
+	if filter.Desc {
+		query = s.queries.selectOrdersByUIDDesc
+	}
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, string(status))
+	}
+	afterTime, afterID := cursorArgs(filter.After)
+
+	ctx, cancel := s.startQuery(ctx, "GetOrdersByUID")
 	defer cancel()
-	if err = s.db.SelectContext(ctx, &orders, s.queries.selectOrdersByUID, UID); err != nil {
+	if err = s.conn(ctx).SelectContext(ctx, &orders, query,
+		UID, statuses, filter.From, filter.Before, afterTime, afterID, limitArg(filter.Limit)); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoOrders
 		}
@@ -150,33 +318,48 @@ func (s *Storage) GetOrdersByUID(ctx context.Context, UID string) (orders []mode
 }
 
 func (s *Storage) GetBalanceByUID(ctx context.Context, UID string) (balance models.Balance, err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	ctx, cancel := s.startQuery(ctx, "GetBalanceByUID")
 	defer cancel()
-	err = s.db.GetContext(ctx, &balance, s.queries.selectBalanceByUID, UID)
+	if err = s.conn(ctx).GetContext(ctx, &balance, s.queries.selectBalanceByUID, UID); errors.Is(err, sql.ErrNoRows) {
+		return balance, models.ErrUserNotFound
+	}
 	return
 }
 
-func (s *Storage) GetCurrentBalanceByUID(ctx context.Context, UID string, tx *sqlx.Tx) (float64, error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) GetCurrentBalanceByUID(ctx context.Context, UID string) (models.Money, error) {
+	ctx, cancel := s.startQuery(ctx, "GetCurrentBalanceByUID")
 	defer cancel()
 	var balance models.Balance
-	if err := tx.GetContext(ctx, &balance, s.queries.selectBalanceByUID, UID); err != nil {
+	if err := s.conn(ctx).GetContext(ctx, &balance, s.queries.selectBalanceByUIDForUpdate, UID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, models.ErrUserNotFound
+		}
 		return -1, err
 	}
 	return balance.Current, nil
 }
 
-func (s *Storage) AddWithdrawal(ctx context.Context, withdrawal models.Withdrawal, tx *sqlx.Tx) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) AddWithdrawal(ctx context.Context, withdrawal models.Withdrawal) (res models.Withdrawal, err error) {
+	ctx, cancel := s.startQuery(ctx, "AddWithdrawal")
 	defer cancel()
-	_, err = tx.NamedExecContext(ctx, s.queries.insertWithdrawals, &withdrawal)
+	err = s.conn(ctx).GetContext(ctx, &res, s.queries.insertWithdrawals, withdrawal.OrderID, withdrawal.UID, withdrawal.Amount)
+	if hasPgErrorCode(err, pgUniqueViolation) {
+		return res, models.ErrWithdrawalAlreadyExists
+	}
 	return
 }
 
-func (s *Storage) GetWithdrawalsByUID(ctx context.Context, UID string) (withdrawals []models.Withdrawal, err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+func (s *Storage) GetWithdrawalsByUID(ctx context.Context, UID string, filter models.WithdrawalFilter) (withdrawals []models.Withdrawal, err error) {
+	query := s.queries.selectWithdrawalsByUID
+	if filter.Desc {
+		query = s.queries.selectWithdrawalsByUIDDesc
+	}
+	afterTime, afterID := cursorArgs(filter.After)
+
+	ctx, cancel := s.startQuery(ctx, "GetWithdrawalsByUID")
 	defer cancel()
-	if err = s.db.SelectContext(ctx, &withdrawals, s.queries.selectWithdrawalsByUID, UID); err != nil {
+	if err = s.conn(ctx).SelectContext(ctx, &withdrawals, query,
+		UID, filter.From, filter.Before, afterTime, afterID, limitArg(filter.Limit)); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoWithdrawals
 		}
@@ -189,36 +372,374 @@ func (s *Storage) GetWithdrawalsByUID(ctx context.Context, UID string) (withdraw
 	return
 }
 
-func (s *Storage) AddOrIncrBalance(ctx context.Context, UID string, ptrBalance *float64, tx *sqlx.Tx) (err error) {
-	var balance float64
-	if ptrBalance != nil {
-		balance = *ptrBalance
+func (s *Storage) GetTotalBalance(ctx context.Context) (total models.Money, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetTotalBalance")
+	defer cancel()
+	err = s.conn(ctx).GetContext(ctx, &total, s.queries.selectTotalBalance)
+	return
+}
+
+func (s *Storage) AddLedgerEntry(ctx context.Context, entry models.LedgerEntry) (added bool, err error) {
+	ctx, cancel := s.startQuery(ctx, "AddLedgerEntry")
+	defer cancel()
+	entry.Account = models.LedgerAccountUser
+	counter := entry.CounterEntry()
+	res, err := s.conn(ctx).NamedExecContext(ctx, s.queries.insertLedgerEntry, &ledgerEntryRow{
+		LedgerEntry:    entry,
+		CounterAccount: counter.Account,
+		CounterKind:    counter.Kind,
+	})
+	if err != nil {
+		return
+	}
+
+	numRowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	return numRowsAffected > 0, nil
+}
+
+// ledgerEntryRow is the user entry inserted along with its counter-entry.
+type ledgerEntryRow struct {
+	models.LedgerEntry
+	CounterAccount models.LedgerAccount   `db:"counter_account"`
+	CounterKind    models.LedgerEntryKind `db:"counter_kind"`
+}
+
+func (s *Storage) ReconcileBalanceByUID(ctx context.Context, UID string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "ReconcileBalanceByUID")
+	defer cancel()
+	if _, err = s.conn(ctx).ExecContext(ctx, s.queries.reconcileBalanceByUID, UID); hasPgErrorCode(err, pgCheckViolation) {
+		return models.ErrInsufficientFunds
+	}
+	return
+}
+
+func (s *Storage) AddRefreshToken(ctx context.Context, token models.RefreshToken) (err error) {
+	ctx, cancel := s.startQuery(ctx, "AddRefreshToken")
+	defer cancel()
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.insertRefreshToken, &token)
+	return
+}
+
+func (s *Storage) GetRefreshTokenByHash(ctx context.Context, hash string) (token models.RefreshToken, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetRefreshTokenByHash")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &token, s.queries.selectRefreshTokenByHash, hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token, models.ErrInvalidRefreshToken
+		}
+		return
+	}
+	return
+}
+
+func (s *Storage) RevokeRefreshToken(ctx context.Context, ID string, replacedBy *string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "RevokeRefreshToken")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.revokeRefreshToken, ID, replacedBy)
+	return
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "RevokeRefreshTokenFamily")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.revokeRefreshTokenFamily, familyID)
+	return
+}
+
+func (s *Storage) RevokeRefreshTokensByUID(ctx context.Context, UID string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "RevokeRefreshTokensByUID")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.revokeRefreshTokensByUID, UID)
+	return
+}
+
+func (s *Storage) AddRevokedToken(ctx context.Context, JTI string, expiresAt time.Time) (err error) {
+	ctx, cancel := s.startQuery(ctx, "AddRevokedToken")
+	defer cancel()
+	if _, err = s.conn(ctx).ExecContext(ctx, s.queries.insertRevokedToken, JTI, expiresAt); err != nil {
+		return
+	}
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteExpiredRevokedTokens)
+	return
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, JTI string, UID string, generation int64) (revoked bool, err error) {
+	ctx, cancel := s.startQuery(ctx, "IsTokenRevoked")
+	defer cancel()
+	err = s.conn(ctx).GetContext(ctx, &revoked, s.queries.selectRevokedTokenExists, JTI, UID, generation)
+	return
+}
+
+func (s *Storage) GetLoginAttempt(ctx context.Context, key string) (attempt models.LoginAttempt, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetLoginAttempt")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &attempt, s.queries.selectLoginAttemptByKey, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempt{Key: key}, nil
+		}
+		return
+	}
+	return
+}
+
+func (s *Storage) AddLoginFailure(ctx context.Context, key string, window time.Duration) (attempt models.LoginAttempt, err error) {
+	ctx, cancel := s.startQuery(ctx, "AddLoginFailure")
+	defer cancel()
+	err = s.conn(ctx).GetContext(ctx, &attempt, s.queries.upsertLoginFailure, key, window.Seconds())
+	return
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) (err error) {
+	ctx, cancel := s.startQuery(ctx, "LockLogin")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.updateLoginLock, key, until)
+	return
+}
+
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "ResetLoginAttempts")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteLoginAttempt, key)
+	return
+}
+
+func (s *Storage) AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (added bool, err error) {
+	ctx, cancel := s.startQuery(ctx, "AddIdempotencyKey")
+	defer cancel()
+	res, err := s.conn(ctx).NamedExecContext(ctx, s.queries.insertIdempotencyKey, &key)
+	if err != nil {
+		return
+	}
+
+	numRowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	return numRowsAffected > 0, nil
+}
+
+func (s *Storage) GetIdempotencyKey(ctx context.Context, UID string, key string) (res models.IdempotencyKey, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetIdempotencyKey")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &res, s.queries.selectIdempotencyKey, UID, key); errors.Is(err, sql.ErrNoRows) {
+		return res, models.ErrIdempotencyKeyNotFound
+	}
+	return
+}
+
+func (s *Storage) SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) (err error) {
+	ctx, cancel := s.startQuery(ctx, "SaveIdempotencyKeyResponse")
+	defer cancel()
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.updateIdempotencyKeyResponse, &key)
+	return
+}
+
+func (s *Storage) DeleteIdempotencyKey(ctx context.Context, UID string, key string) (err error) {
+	ctx, cancel := s.startQuery(ctx, "DeleteIdempotencyKey")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteIdempotencyKey, UID, key)
+	return
+}
+
+func (s *Storage) DeleteExpiredIdempotencyKeys(ctx context.Context) (err error) {
+	ctx, cancel := s.startQuery(ctx, "DeleteExpiredIdempotencyKeys")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteExpiredIdempotencyKeys)
+	return
+}
+
+// AddOrderEvent holds a per-user lock until the transaction ends. A bigserial
+// ID is taken at insert but becomes visible at commit, so without the lock a
+// later ID could be committed first and skipped by a stream resuming after it.
+func (s *Storage) AddOrderEvent(ctx context.Context, event models.OrderEvent) (res models.OrderEvent, err error) {
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		ctx, cancel := s.startQuery(ctx, "AddOrderEvent")
+		defer cancel()
+		if _, err := s.conn(ctx).ExecContext(ctx, s.queries.lockOrderEventsByUID, event.UID); err != nil {
+			return err
+		}
+		return s.conn(ctx).GetContext(ctx, &res, s.queries.insertOrderEvent, event.UID, event.OrderID, event.Status, event.Accrual)
+	})
+	return
+}
+
+func (s *Storage) GetOrderEventsByUID(ctx context.Context, UID string, afterID int64, limit int) (events []models.OrderEvent, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetOrderEventsByUID")
+	defer cancel()
+	err = s.conn(ctx).SelectContext(ctx, &events, s.queries.selectOrderEventsByUID, UID, afterID, limit)
+	return
+}
+
+func (s *Storage) DeleteOrderEventsBefore(ctx context.Context, before time.Time) (err error) {
+	ctx, cancel := s.startQuery(ctx, "DeleteOrderEventsBefore")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteOrderEventsBefore, before)
+	return
+}
+
+func (s *Storage) AddWebhook(ctx context.Context, webhook models.Webhook) (err error) {
+	ctx, cancel := s.startQuery(ctx, "AddWebhook")
+	defer cancel()
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.insertWebhook, &webhook)
+	return
+}
+
+func (s *Storage) GetWebhookByID(ctx context.Context, ID string) (webhook models.Webhook, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetWebhookByID")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &webhook, s.queries.selectWebhookByID, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return webhook, models.ErrWebhookNotFound
+		}
+		return
 	}
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	return
+}
+
+func (s *Storage) GetWebhooksByUID(ctx context.Context, UID string) (webhooks []models.Webhook, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetWebhooksByUID")
 	defer cancel()
-	_, err = tx.ExecContext(ctx, s.queries.insertOrUpdateBalancesByUID, balance, UID)
+	err = s.conn(ctx).SelectContext(ctx, &webhooks, s.queries.selectWebhooksByUID, UID)
 	return
 }
-func (s *Storage) IncrBalanceWithdrawnByUID(ctx context.Context, UID string, value float64, tx *sqlx.Tx) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+
+func (s *Storage) DeleteWebhook(ctx context.Context, UID string, ID string) error {
+	ctx, cancel := s.startQuery(ctx, "DeleteWebhook")
 	defer cancel()
-	_, err = tx.ExecContext(ctx, s.queries.updateBalanceWithdrawnByUID, value, UID)
+	res, err := s.conn(ctx).ExecContext(ctx, s.queries.deleteWebhook, ID, UID)
+	if err != nil {
+		return err
+	}
+
+	numRowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if numRowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *Storage) AddWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) (err error) {
+	ctx, cancel := s.startQuery(ctx, "AddWebhookDelivery")
+	defer cancel()
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.insertWebhookDelivery, &delivery)
+	return
+}
+
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []models.WebhookDelivery, err error) {
+	ctx, cancel := s.startQuery(ctx, "ClaimWebhookDeliveries")
+	defer cancel()
+	err = s.conn(ctx).SelectContext(ctx, &deliveries, s.queries.claimWebhookDeliveries, limit, lease.Seconds())
+	return
+}
+
+func (s *Storage) UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) (err error) {
+	ctx, cancel := s.startQuery(ctx, "UpdateWebhookDelivery")
+	defer cancel()
+	_, err = s.conn(ctx).NamedExecContext(ctx, s.queries.updateWebhookDelivery, &delivery)
+	return
+}
+
+func (s *Storage) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) (err error) {
+	ctx, cancel := s.startQuery(ctx, "DeleteWebhookDeliveriesBefore")
+	defer cancel()
+	_, err = s.conn(ctx).ExecContext(ctx, s.queries.deleteWebhookDeliveriesBefore, before)
+	return
+}
+
+func (s *Storage) GetWebhookDeliveryByID(ctx context.Context, ID string) (delivery models.WebhookDelivery, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetWebhookDeliveryByID")
+	defer cancel()
+	if err = s.conn(ctx).GetContext(ctx, &delivery, s.queries.selectWebhookDeliveryByID, ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return delivery, models.ErrWebhookDeliveryNotFound
+		}
+		return
+	}
+	return
+}
+
+func (s *Storage) GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) (deliveries []models.WebhookDelivery, err error) {
+	ctx, cancel := s.startQuery(ctx, "GetWebhookDeliveriesByWebhookID")
+	defer cancel()
+	err = s.conn(ctx).SelectContext(ctx, &deliveries, s.queries.selectWebhookDeliveriesByWebhookID, webhookID, limit)
 	return
 }
 
 func (s *Storage) GetUnfinishedOrders(ctx context.Context) (orders []models.Order, err error) {
-	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	ctx, cancel := s.startQuery(ctx, "GetUnfinishedOrders")
 	defer cancel()
-	err = s.db.SelectContext(ctx, &orders, s.queries.selectOrdersByStatuses, models.AccrualStatusNew, models.AccrualStatusProcessing)
+	err = s.conn(ctx).SelectContext(ctx, &orders, s.queries.selectOrdersByStatuses, models.AccrualStatusNew, models.AccrualStatusProcessing)
 	return
 }
 
-func (s *Storage) Transaction(ctx context.Context, f func(ctx context.Context, tx *sqlx.Tx) error) (err error) {
-	ctx, cancel := context.WithTimeout(ctx, 2*queryTimeout)
+func (s *Storage) Ping(ctx context.Context) error {
+	ctx, cancel := s.startQuery(ctx, "Ping")
+	defer cancel()
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) CheckMigrations(ctx context.Context) error {
+	ctx, cancel := s.startQuery(ctx, "CheckMigrations")
+	defer cancel()
+	var current int64
+	if err := s.conn(ctx).GetContext(ctx, &current, s.queries.selectMigrationsVersion); err != nil {
+		return err
+	}
+	if current < s.migrationsVersion {
+		return fmt.Errorf("%w: version %d, latest %d", models.ErrMigrationsPending, current, s.migrationsVersion)
+	}
+	return nil
+}
+
+// Transaction runs f in a database transaction carried by the context passed
+// to f. Nested calls join the outer transaction. Transactions run at READ
+// COMMITTED, the balance is protected by row locks, and deadlocks restart the
+// whole transaction, so f must be safe to run again.
+func (s *Storage) Transaction(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txCtxKey{}).(*sqlx.Tx); ok {
+		return f(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, "postgres.Transaction", trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err = s.transaction(ctx, f)
+		if attempt == txMaxAttempts || !hasPgErrorCode(err, pgDeadlockDetected) {
+			return
+		}
+
+		logger.FromContext(ctx).Debug("transaction: retry", zap.Int("attempt", attempt), zap.Error(err))
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+		metrics.DBTransactionRetries.Inc()
+		select {
+		case <-time.After(delay + time.Duration(rand.Int63n(int64(delay)))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+func (s *Storage) transaction(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*s.queryTimeout)
 	defer cancel()
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		zap.L().Warn("transaction: begin", zap.Error(err))
+		logger.FromContext(ctx).Warn("transaction: begin", zap.Error(err))
 		return
 	}
 
@@ -230,14 +751,38 @@ func (s *Storage) Transaction(ctx context.Context, f func(ctx context.Context, t
 			zap.L().Fatal("transaction: panic", zap.Any("panic", p))
 		case err != nil:
 			_ = tx.Rollback()
-			zap.L().Warn("transaction: error", zap.Error(err))
+			logger.FromContext(ctx).Warn("transaction: error", zap.Error(err))
 		default:
 			if err = tx.Commit(); err != nil {
-				zap.L().Fatal("transaction: commit", zap.Error(err))
+				logger.FromContext(ctx).Warn("transaction: commit", zap.Error(err))
 			}
 		}
 	}()
-	return f(ctx, tx)
+	return f(context.WithValue(ctx, txCtxKey{}, tx))
+}
+
+// cursorArgs returns the query arguments for the page cursor, NULL if there is none.
+func cursorArgs(c *models.Cursor) (*time.Time, *string) {
+	if c == nil {
+		return nil, nil
+	}
+	return &c.Time, &c.ID
+}
+
+// limitArg passes zero limit as NULL, which is no limit at all.
+func limitArg(limit int) *int {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
+
+// conn returns the transaction carried by ctx or the database handle.
+func (s *Storage) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txCtxKey{}).(*sqlx.Tx); ok {
+		return tracedQuerier{tx}
+	}
+	return tracedQuerier{s.db}
 }
 
 func (s *Storage) setQueries(_ context.Context) error {
@@ -253,12 +798,17 @@ func (s *Storage) setQueries(_ context.Context) error {
 		}
 
 		switch file.Name() {
-		case "insert_or_update_balances_by_uid.sql":
-			s.queries.insertOrUpdateBalancesByUID = query
-		case "update_balance_withdrawn_by_uid.sql":
-			s.queries.updateBalanceWithdrawnByUID = query
+		case "reconcile_balance_by_uid.sql":
+			s.queries.reconcileBalanceByUID = query
 		case "select_balance_by_uid.sql":
 			s.queries.selectBalanceByUID = query
+		case "select_balance_by_uid_for_update.sql":
+			s.queries.selectBalanceByUIDForUpdate = query
+		case "select_total_balance.sql":
+			s.queries.selectTotalBalance = query
+
+		case "insert_ledger_entry.sql":
+			s.queries.insertLedgerEntry = query
 
 		case "insert_order.sql":
 			s.queries.insertOrder = query
@@ -268,6 +818,8 @@ func (s *Storage) setQueries(_ context.Context) error {
 			s.queries.selectOrderByID = query
 		case "select_orders_by_uid.sql":
 			s.queries.selectOrdersByUID = query
+		case "select_orders_by_uid_desc.sql":
+			s.queries.selectOrdersByUIDDesc = query
 		case "select_orders_by_statuses.sql":
 			s.queries.selectOrdersByStatuses = query
 
@@ -275,11 +827,89 @@ func (s *Storage) setQueries(_ context.Context) error {
 			s.queries.insertUser = query
 		case "select_user_by_login.sql":
 			s.queries.selectUserByLogin = query
+		case "select_user_by_id.sql":
+			s.queries.selectUserByID = query
+		case "update_user_password.sql":
+			s.queries.updateUserPassword = query
+		case "anonymize_user.sql":
+			s.queries.anonymizeUser = query
 
 		case "insert_withdrawals.sql":
 			s.queries.insertWithdrawals = query
 		case "select_withdrawals_by_uid.sql":
 			s.queries.selectWithdrawalsByUID = query
+		case "select_withdrawals_by_uid_desc.sql":
+			s.queries.selectWithdrawalsByUIDDesc = query
+
+		case "insert_refresh_token.sql":
+			s.queries.insertRefreshToken = query
+		case "select_refresh_token_by_hash.sql":
+			s.queries.selectRefreshTokenByHash = query
+		case "revoke_refresh_token.sql":
+			s.queries.revokeRefreshToken = query
+		case "revoke_refresh_token_family.sql":
+			s.queries.revokeRefreshTokenFamily = query
+		case "revoke_refresh_tokens_by_uid.sql":
+			s.queries.revokeRefreshTokensByUID = query
+		case "insert_revoked_token.sql":
+			s.queries.insertRevokedToken = query
+		case "delete_expired_revoked_tokens.sql":
+			s.queries.deleteExpiredRevokedTokens = query
+		case "select_revoked_token_exists.sql":
+			s.queries.selectRevokedTokenExists = query
+
+		case "select_login_attempt_by_key.sql":
+			s.queries.selectLoginAttemptByKey = query
+		case "upsert_login_failure.sql":
+			s.queries.upsertLoginFailure = query
+		case "update_login_lock.sql":
+			s.queries.updateLoginLock = query
+		case "delete_login_attempt.sql":
+			s.queries.deleteLoginAttempt = query
+
+		case "insert_idempotency_key.sql":
+			s.queries.insertIdempotencyKey = query
+		case "select_idempotency_key.sql":
+			s.queries.selectIdempotencyKey = query
+		case "update_idempotency_key_response.sql":
+			s.queries.updateIdempotencyKeyResponse = query
+		case "delete_idempotency_key.sql":
+			s.queries.deleteIdempotencyKey = query
+		case "delete_expired_idempotency_keys.sql":
+			s.queries.deleteExpiredIdempotencyKeys = query
+
+		case "lock_order_events_by_uid.sql":
+			s.queries.lockOrderEventsByUID = query
+		case "insert_order_event.sql":
+			s.queries.insertOrderEvent = query
+		case "select_order_events_by_uid.sql":
+			s.queries.selectOrderEventsByUID = query
+		case "delete_order_events_before.sql":
+			s.queries.deleteOrderEventsBefore = query
+
+		case "insert_webhook.sql":
+			s.queries.insertWebhook = query
+		case "select_webhook_by_id.sql":
+			s.queries.selectWebhookByID = query
+		case "select_webhooks_by_uid.sql":
+			s.queries.selectWebhooksByUID = query
+		case "delete_webhook.sql":
+			s.queries.deleteWebhook = query
+		case "insert_webhook_delivery.sql":
+			s.queries.insertWebhookDelivery = query
+		case "claim_webhook_deliveries.sql":
+			s.queries.claimWebhookDeliveries = query
+		case "update_webhook_delivery.sql":
+			s.queries.updateWebhookDelivery = query
+		case "select_webhook_delivery_by_id.sql":
+			s.queries.selectWebhookDeliveryByID = query
+		case "select_webhook_deliveries_by_webhook_id.sql":
+			s.queries.selectWebhookDeliveriesByWebhookID = query
+		case "delete_webhook_deliveries_before.sql":
+			s.queries.deleteWebhookDeliveriesBefore = query
+
+		case "select_migrations_version.sql":
+			s.queries.selectMigrationsVersion = query
 		}
 	}
 	return err
@@ -292,7 +922,19 @@ func (s *Storage) migrate(_ context.Context) (err error) {
 		return
 	}
 
-	err = goose.Up(s.db.DB, migrationsFsName)
+	if err = goose.Up(s.db.DB, migrationsFsName); err != nil {
+		return
+	}
+
+	migrations, err := goose.CollectMigrations(migrationsFsName, 0, goose.MaxVersion)
+	if err != nil {
+		return
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		return
+	}
+	s.migrationsVersion = latest.Version
 	return
 }
 
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+	"github.com/stsg/gophermart2/internal/storages/storagetest"
+)
+
+// testDatabaseURIEnv names the database the tests migrate and write to.
+// The tests are skipped without it.
+const testDatabaseURIEnv = "TEST_DATABASE_URI"
+
+// newTestStorage connects to the test database, bypassing New, which reads
+// the service configuration and exits on errors.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	uri := os.Getenv(testDatabaseURIEnv)
+	if uri == "" {
+		t.Skipf("%s is not set", testDatabaseURIEnv)
+	}
+
+	db, err := sqlx.Connect("pgx", uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	s := &Storage{db: db, queryTimeout: 5 * time.Second}
+	if err = s.migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.setQueries(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storages.Storager {
+		return newTestStorage(t)
+	})
+}
+
+func TestLedgerCounterEntries(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	UID, orderID := uuid.NewString(), uuid.NewString()
+
+	added, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryCredit, OrderID: orderID, Amount: 1000})
+	if err != nil || !added {
+		t.Fatalf("AddLedgerEntry = %v, %v", added, err)
+	}
+
+	var entries []models.LedgerEntry
+	if err = s.db.SelectContext(ctx, &entries, "SELECT uid, account, kind, order_id, amount, created_at FROM ledger WHERE order_id=$1 ORDER BY account", orderID); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 ||
+		entries[0].Account != models.LedgerAccountAccrual || entries[0].Kind != models.LedgerEntryDebit || entries[0].Amount != 1000 ||
+		entries[1].Account != models.LedgerAccountUser || entries[1].Kind != models.LedgerEntryCredit || entries[1].Amount != 1000 {
+		t.Fatalf("ledger entries = %+v", entries)
+	}
+
+	// an unbalanced entry fails the deferred check
+	_, err = s.db.ExecContext(ctx, "INSERT INTO ledger(uid, account, kind, order_id, amount) VALUES($1, 'user', 'credit', $2, 1)", UID, uuid.NewString())
+	if err == nil {
+		t.Fatal("unbalanced ledger entry accepted")
+	}
+}
+
+// TestCheckMigrationsRollback records a rollback of the latest migration: the
+// latest row of each version decides whether it is applied, as in goose.
+func TestCheckMigrationsRollback(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	if err := s.CheckMigrations(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	errRollback := errors.New("rollback")
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		const insert = "INSERT INTO goose_db_version(version_id, is_applied) VALUES($1, $2)"
+		if _, err := s.conn(ctx).ExecContext(ctx, insert, s.migrationsVersion, false); err != nil {
+			return err
+		}
+		if err := s.CheckMigrations(ctx); !errors.Is(err, models.ErrMigrationsPending) {
+			t.Errorf("CheckMigrations after rollback = %v, want %v", err, models.ErrMigrationsPending)
+		}
+
+		if _, err := s.conn(ctx).ExecContext(ctx, insert, s.migrationsVersion, true); err != nil {
+			return err
+		}
+		if err := s.CheckMigrations(ctx); err != nil {
+			t.Errorf("CheckMigrations after reapplying = %v", err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+}
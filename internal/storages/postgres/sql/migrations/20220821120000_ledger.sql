@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger (
+id bigserial NOT NULL PRIMARY KEY,
+uid uuid NOT NULL,
+kind text NOT NULL CHECK (kind IN ('credit', 'debit')),
+order_id text NOT NULL,
+amount float NOT NULL CHECK (amount > 0),
+created_at timestamptz NOT NULL DEFAULT NOW(),
+UNIQUE (kind, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_uid_idx ON ledger (uid);
+
+INSERT INTO ledger(uid, kind, order_id, amount, created_at)
+SELECT uid, 'credit', id, accrual, uploaded_at FROM orders WHERE accrual_status='PROCESSED' AND accrual > 0
+ON CONFLICT (kind, order_id) DO NOTHING;
+
+INSERT INTO ledger(uid, kind, order_id, amount, created_at)
+SELECT uid, 'debit', order_id, amount, processed_at FROM withdrawals WHERE amount > 0
+ON CONFLICT (kind, order_id) DO NOTHING;
+
+INSERT INTO balances(uid, current_balance, withdrawn)
+SELECT uid,
+       SUM(CASE WHEN kind='credit' THEN amount ELSE -amount END),
+       SUM(CASE WHEN kind='debit' THEN amount ELSE 0 END)
+FROM ledger GROUP BY uid
+ON CONFLICT (uid) DO UPDATE SET current_balance=EXCLUDED.current_balance, withdrawn=EXCLUDED.withdrawn;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE balances
+    ALTER COLUMN current_balance TYPE numeric(20, 2) USING round(current_balance::numeric, 2),
+    ALTER COLUMN withdrawn TYPE numeric(20, 2) USING round(withdrawn::numeric, 2);
+
+ALTER TABLE orders
+    ALTER COLUMN accrual TYPE numeric(20, 2) USING round(accrual::numeric, 2);
+
+ALTER TABLE withdrawals
+    ALTER COLUMN amount TYPE numeric(20, 2) USING round(amount::numeric, 2);
+
+ALTER TABLE ledger
+    ALTER COLUMN amount TYPE numeric(20, 2) USING round(amount::numeric, 2);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE balances
+    ALTER COLUMN current_balance TYPE float USING current_balance::float,
+    ALTER COLUMN withdrawn TYPE float USING withdrawn::float;
+
+ALTER TABLE orders
+    ALTER COLUMN accrual TYPE float USING accrual::float;
+
+ALTER TABLE withdrawals
+    ALTER COLUMN amount TYPE float USING amount::float;
+
+ALTER TABLE ledger
+    ALTER COLUMN amount TYPE float USING amount::float;
+-- +goose StatementEnd
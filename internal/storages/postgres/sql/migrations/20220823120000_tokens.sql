@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+id uuid UNIQUE NOT NULL PRIMARY KEY,
+family_id uuid NOT NULL,
+uid uuid NOT NULL,
+token_hash text UNIQUE NOT NULL,
+expires_at timestamptz NOT NULL,
+created_at timestamptz NOT NULL DEFAULT NOW(),
+revoked_at timestamptz,
+replaced_by uuid
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+jti uuid UNIQUE NOT NULL PRIMARY KEY,
+expires_at timestamptz NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS revoked_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS login_attempts (
+key text UNIQUE NOT NULL PRIMARY KEY,
+failures integer NOT NULL DEFAULT 0,
+locked_until timestamptz,
+updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_attempts;
+-- +goose StatementEnd
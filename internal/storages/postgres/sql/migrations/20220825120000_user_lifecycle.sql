@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS tokens_revoked_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_uid_idx ON refresh_tokens (uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS refresh_tokens_uid_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS tokens_revoked_at,
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
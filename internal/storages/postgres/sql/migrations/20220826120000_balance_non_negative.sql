@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE balances
+    ADD CONSTRAINT balances_current_balance_non_negative CHECK (current_balance >= 0) NOT VALID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE balances
+    DROP CONSTRAINT IF EXISTS balances_current_balance_non_negative;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+uid uuid NOT NULL,
+key text NOT NULL,
+fingerprint text NOT NULL,
+status_code integer,
+content_type text NOT NULL DEFAULT '',
+body bytea,
+created_at timestamptz NOT NULL DEFAULT NOW(),
+expires_at timestamptz NOT NULL,
+PRIMARY KEY (uid, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
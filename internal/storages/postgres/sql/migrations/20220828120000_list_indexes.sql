@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS orders_uid_uploaded_at_idx ON orders (uid, uploaded_at, id);
+CREATE INDEX IF NOT EXISTS withdrawals_uid_processed_at_idx ON withdrawals (uid, processed_at, order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS orders_uid_uploaded_at_idx;
+DROP INDEX IF EXISTS withdrawals_uid_processed_at_idx;
+-- +goose StatementEnd
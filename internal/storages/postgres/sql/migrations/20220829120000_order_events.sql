@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS order_events (
+id bigserial NOT NULL PRIMARY KEY,
+uid uuid NOT NULL,
+order_id text NOT NULL,
+status text NOT NULL,
+accrual numeric(20, 2),
+created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_events_uid_id_idx ON order_events (uid, id);
+CREATE INDEX IF NOT EXISTS order_events_created_at_idx ON order_events (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_events;
+-- +goose StatementEnd
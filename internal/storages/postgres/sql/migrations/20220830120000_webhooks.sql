@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhooks (
+id uuid UNIQUE NOT NULL PRIMARY KEY,
+uid uuid NOT NULL,
+url text NOT NULL,
+secret text NOT NULL,
+created_at timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_uid_idx ON webhooks (uid);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+id uuid UNIQUE NOT NULL PRIMARY KEY,
+webhook_id uuid NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+uid uuid NOT NULL,
+event_type text NOT NULL,
+payload jsonb NOT NULL,
+status text NOT NULL,
+attempts integer NOT NULL DEFAULT 0,
+next_attempt_at timestamptz NOT NULL DEFAULT NOW(),
+last_status_code integer,
+last_error text,
+created_at timestamptz NOT NULL DEFAULT NOW(),
+delivered_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+-- +goose StatementEnd
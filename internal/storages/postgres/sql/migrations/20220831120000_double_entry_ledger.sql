@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ledger
+    ADD COLUMN account text NOT NULL DEFAULT 'user' CHECK (account IN ('user', 'accrual', 'withdrawals'));
+
+ALTER TABLE ledger
+    DROP CONSTRAINT IF EXISTS ledger_kind_order_id_key,
+    ADD CONSTRAINT ledger_account_kind_order_id_key UNIQUE (account, kind, order_id);
+
+CREATE INDEX IF NOT EXISTS ledger_order_id_idx ON ledger (order_id);
+
+INSERT INTO ledger(uid, account, kind, order_id, amount, created_at)
+SELECT uid,
+       CASE WHEN kind='credit' THEN 'accrual' ELSE 'withdrawals' END,
+       CASE WHEN kind='credit' THEN 'debit' ELSE 'credit' END,
+       order_id, amount, created_at
+FROM ledger WHERE account='user'
+ON CONFLICT (account, kind, order_id) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION ledger_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT SUM(CASE WHEN kind='credit' THEN amount ELSE -amount END) FROM ledger WHERE order_id=NEW.order_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger entries of order % do not balance', NEW.order_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_balanced
+    AFTER INSERT OR UPDATE ON ledger
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS ledger_balanced ON ledger;
+DROP FUNCTION IF EXISTS ledger_check_balanced();
+
+DELETE FROM ledger WHERE account<>'user';
+DROP INDEX IF EXISTS ledger_order_id_idx;
+
+ALTER TABLE ledger
+    DROP CONSTRAINT IF EXISTS ledger_account_kind_order_id_key,
+    ADD CONSTRAINT ledger_kind_order_id_key UNIQUE (kind, order_id);
+
+ALTER TABLE ledger
+    DROP COLUMN IF EXISTS account;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_generation bigint NOT NULL DEFAULT 0;
+
+-- tokens issued before this migration carry no generation, keep them revoked
+UPDATE users SET token_generation=1 WHERE tokens_revoked_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS token_generation;
+-- +goose StatementEnd
@@ -0,0 +1 @@
+UPDATE users SET login='deleted:' || id::text, password='', deleted_at=NOW(), tokens_revoked_at=NOW(), token_generation=token_generation+1 WHERE id=$1 AND deleted_at IS NULL
\ No newline at end of file
@@ -0,0 +1,6 @@
+UPDATE webhook_deliveries SET next_attempt_at=NOW() + make_interval(secs => $2)
+WHERE id IN (
+SELECT id FROM webhook_deliveries WHERE status='pending' AND next_attempt_at <= NOW()
+ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED
+)
+RETURNING id, webhook_id, uid, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at
\ No newline at end of file
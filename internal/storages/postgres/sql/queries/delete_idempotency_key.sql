@@ -0,0 +1 @@
+DELETE FROM idempotency_keys WHERE uid=$1 AND key=$2
\ No newline at end of file
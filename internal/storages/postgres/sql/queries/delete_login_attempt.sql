@@ -0,0 +1 @@
+DELETE FROM login_attempts WHERE key=$1
\ No newline at end of file
@@ -0,0 +1 @@
+DELETE FROM order_events WHERE created_at < $1
\ No newline at end of file
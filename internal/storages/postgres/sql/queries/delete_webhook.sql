@@ -0,0 +1 @@
+DELETE FROM webhooks WHERE id=$1 AND uid=$2
\ No newline at end of file
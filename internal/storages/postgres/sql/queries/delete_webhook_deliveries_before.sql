@@ -0,0 +1 @@
+DELETE FROM webhook_deliveries WHERE status<>'pending' AND created_at < $1
\ No newline at end of file
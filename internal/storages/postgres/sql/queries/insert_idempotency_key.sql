@@ -0,0 +1,4 @@
+INSERT INTO idempotency_keys(uid, key, fingerprint, expires_at) VALUES(:uid, :key, :fingerprint, :expires_at)
+ON CONFLICT (uid, key) DO UPDATE SET
+fingerprint=EXCLUDED.fingerprint, status_code=NULL, content_type='', body=NULL, created_at=NOW(), expires_at=EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
\ No newline at end of file
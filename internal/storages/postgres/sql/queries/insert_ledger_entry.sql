@@ -0,0 +1,7 @@
+WITH entry AS (
+INSERT INTO ledger(uid, account, kind, order_id, amount) VALUES(:uid, :account, :kind, :order_id, :amount)
+ON CONFLICT (account, kind, order_id) DO NOTHING
+RETURNING uid, kind, order_id, amount
+)
+INSERT INTO ledger(uid, account, kind, order_id, amount)
+SELECT uid, CAST(:counter_account AS text), CAST(:counter_kind AS text), order_id, amount FROM entry
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO order_events(uid, order_id, status, accrual) VALUES($1, $2, $3, $4)
+RETURNING id, uid, order_id, status, accrual, created_at
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO refresh_tokens(id, family_id, uid, token_hash, expires_at) VALUES(:id, :family_id, :uid, :token_hash, :expires_at)
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO revoked_tokens(jti, expires_at) VALUES($1, $2) ON CONFLICT (jti) DO NOTHING
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO webhooks(id, uid, url, secret) VALUES(:id, :uid, :url, :secret)
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO webhook_deliveries(id, webhook_id, uid, event_type, payload, status) VALUES(:id, :webhook_id, :uid, :event_type, :payload, :status)
\ No newline at end of file
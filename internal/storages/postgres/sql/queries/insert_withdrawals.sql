@@ -1 +1,2 @@
-INSERT INTO withdrawals(order_id, uid, amount) VALUES(:order_id, :uid, :amount)
+INSERT INTO withdrawals(order_id, uid, amount) VALUES($1, $2, $3)
+RETURNING order_id, uid, amount, processed_at
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT pg_advisory_xact_lock(hashtext('order_events'), hashtext($1::text))
\ No newline at end of file
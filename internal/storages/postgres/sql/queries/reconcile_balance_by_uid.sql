@@ -0,0 +1,6 @@
+INSERT INTO balances(uid, current_balance, withdrawn)
+SELECT $1::uuid,
+       COALESCE(SUM(CASE WHEN kind='credit' THEN amount ELSE -amount END), 0),
+       COALESCE(SUM(CASE WHEN kind='debit' THEN amount ELSE 0 END), 0)
+FROM ledger WHERE uid=$1::uuid AND account='user'
+ON CONFLICT(uid) DO UPDATE SET current_balance=EXCLUDED.current_balance, withdrawn=EXCLUDED.withdrawn
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE refresh_tokens SET revoked_at=NOW(), replaced_by=$2 WHERE id=$1 AND revoked_at IS NULL
\ No newline at end of file
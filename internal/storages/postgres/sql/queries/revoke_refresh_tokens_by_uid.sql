@@ -0,0 +1 @@
+UPDATE refresh_tokens SET revoked_at=NOW() WHERE uid=$1 AND revoked_at IS NULL
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT uid, current_balance, withdrawn FROM balances WHERE uid=$1 LIMIT 1 FOR UPDATE
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT uid, key, fingerprint, status_code, content_type, body, created_at, expires_at FROM idempotency_keys WHERE uid=$1 AND key=$2 AND expires_at > NOW() LIMIT 1
\ No newline at end of file
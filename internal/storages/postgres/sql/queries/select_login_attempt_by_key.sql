@@ -0,0 +1 @@
+SELECT key, failures, locked_until, updated_at FROM login_attempts WHERE key=$1 LIMIT 1
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT COALESCE(MAX(version_id), 0) FROM (
+SELECT DISTINCT ON (version_id) version_id, is_applied FROM goose_db_version ORDER BY version_id, id DESC
+) AS latest WHERE is_applied
\ No newline at end of file
@@ -1 +1 @@
-SELECT id, uid, accrual, accrual_status FROM orders WHERE id=$1 LIMIT 1
+SELECT id, uid, accrual, accrual_status, uploaded_at FROM orders WHERE id=$1 LIMIT 1
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT id, uid, order_id, status, accrual, created_at FROM order_events WHERE uid=$1 AND id > $2 ORDER BY id LIMIT $3
\ No newline at end of file
@@ -1 +1 @@
-SELECT id, uid FROM orders WHERE accrual_status IN ($1, $2)
+SELECT id, uid, accrual_status FROM orders WHERE accrual_status IN ($1, $2)
\ No newline at end of file
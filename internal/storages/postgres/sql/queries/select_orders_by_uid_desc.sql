@@ -0,0 +1,8 @@
+SELECT id, accrual, accrual_status, uploaded_at FROM orders
+WHERE uid=$1
+AND (COALESCE(cardinality($2::text[]), 0) = 0 OR accrual_status = ANY($2::text[]))
+AND ($3::timestamptz IS NULL OR uploaded_at >= $3::timestamptz)
+AND ($4::timestamptz IS NULL OR uploaded_at < $4::timestamptz)
+AND ($5::timestamptz IS NULL OR (uploaded_at, id) < ($5::timestamptz, $6::text))
+ORDER BY uploaded_at DESC, id DESC
+LIMIT $7
\ No newline at end of file
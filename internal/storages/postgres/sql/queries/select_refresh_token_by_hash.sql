@@ -0,0 +1 @@
+SELECT id, family_id, uid, token_hash, expires_at, created_at, revoked_at, replaced_by FROM refresh_tokens WHERE token_hash=$1 LIMIT 1 FOR UPDATE
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti=$1)
+    OR EXISTS(SELECT 1 FROM users WHERE id=$2 AND (deleted_at IS NOT NULL OR token_generation > $3))
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT COALESCE(SUM(current_balance), 0) FROM balances
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT id, login, password, created_at, token_generation FROM users WHERE id=$1 AND deleted_at IS NULL
\ No newline at end of file
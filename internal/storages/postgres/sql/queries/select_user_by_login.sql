@@ -1 +1 @@
-SELECT id, login, password, created_at FROM users WHERE login=$1
+SELECT id, login, password, created_at, token_generation FROM users WHERE login=$1
@@ -0,0 +1 @@
+SELECT id, uid, url, secret, created_at FROM webhooks WHERE id=$1 LIMIT 1
\ No newline at end of file
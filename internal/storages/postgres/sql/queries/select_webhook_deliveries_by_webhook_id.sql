@@ -0,0 +1 @@
+SELECT id, webhook_id, uid, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY created_at DESC, id DESC LIMIT $2
\ No newline at end of file
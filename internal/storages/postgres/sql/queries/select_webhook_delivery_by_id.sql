@@ -0,0 +1 @@
+SELECT id, webhook_id, uid, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at FROM webhook_deliveries WHERE id=$1 LIMIT 1
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT id, uid, url, secret, created_at FROM webhooks WHERE uid=$1 ORDER BY created_at
\ No newline at end of file
@@ -1 +1,7 @@
-SELECT order_id, amount, processed_at FROM withdrawals WHERE uid=$1
+SELECT order_id, amount, processed_at FROM withdrawals
+WHERE uid=$1
+AND ($2::timestamptz IS NULL OR processed_at >= $2::timestamptz)
+AND ($3::timestamptz IS NULL OR processed_at < $3::timestamptz)
+AND ($4::timestamptz IS NULL OR (processed_at, order_id) > ($4::timestamptz, $5::text))
+ORDER BY processed_at ASC, order_id ASC
+LIMIT $6
\ No newline at end of file
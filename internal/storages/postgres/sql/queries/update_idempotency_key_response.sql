@@ -0,0 +1,2 @@
+UPDATE idempotency_keys SET status_code=:status_code, content_type=:content_type, body=:body, expires_at=:expires_at
+WHERE uid=:uid AND key=:key AND fingerprint=:fingerprint AND status_code IS NULL
\ No newline at end of file
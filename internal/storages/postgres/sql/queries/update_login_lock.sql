@@ -0,0 +1 @@
+UPDATE login_attempts SET locked_until=$2 WHERE key=$1
\ No newline at end of file
@@ -1 +1 @@
-UPDATE orders SET accrual=:accrual, accrual_status=:accrual_status
+UPDATE orders SET accrual=:accrual, accrual_status=:accrual_status WHERE id=:id AND accrual_status NOT IN ('PROCESSED', 'INVALID') AND (accrual_status='NEW' OR CAST(:accrual_status AS text)<>'NEW')
\ No newline at end of file
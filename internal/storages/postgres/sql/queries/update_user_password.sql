@@ -0,0 +1 @@
+UPDATE users SET password=$2, tokens_revoked_at=NOW(), token_generation=token_generation+1 WHERE id=$1 AND deleted_at IS NULL
\ No newline at end of file
@@ -0,0 +1 @@
+UPDATE webhook_deliveries SET status=:status, attempts=:attempts, next_attempt_at=:next_attempt_at, last_status_code=:last_status_code, last_error=:last_error, delivered_at=:delivered_at WHERE id=:id
\ No newline at end of file
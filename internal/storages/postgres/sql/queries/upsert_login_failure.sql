@@ -0,0 +1,5 @@
+INSERT INTO login_attempts(key, failures, updated_at) VALUES($1, 1, NOW())
+ON CONFLICT(key) DO UPDATE SET
+failures=(CASE WHEN login_attempts.updated_at < NOW() - make_interval(secs => $2) THEN 1 ELSE login_attempts.failures+1 END),
+updated_at=NOW()
+RETURNING key, failures, locked_until, updated_at
\ No newline at end of file
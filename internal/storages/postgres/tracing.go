@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/stsg/gophermart2/internal/storages/postgres")
+
+// startQuery starts the span of the storage method and limits it with the
+// query timeout. The returned cancel also ends the span.
+func (s *Storage) startQuery(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	ctx, span := tracer.Start(ctx, "postgres."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+	ctx, cancel := context.WithTimeout(ctx, s.queryTimeout)
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
+
+// tracedQuerier records the statements and their errors on the current span.
+type tracedQuerier struct {
+	querier
+}
+
+func (q tracedQuerier) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	err := q.querier.GetContext(ctx, dest, query, args...)
+	recordQuery(ctx, query, err)
+	return err
+}
+
+func (q tracedQuerier) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	err := q.querier.SelectContext(ctx, dest, query, args...)
+	recordQuery(ctx, query, err)
+	return err
+}
+
+func (q tracedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	res, err := q.querier.ExecContext(ctx, query, args...)
+	recordQuery(ctx, query, err)
+	return res, err
+}
+
+func (q tracedQuerier) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	res, err := q.querier.NamedExecContext(ctx, query, arg)
+	recordQuery(ctx, query, err)
+	return res, err
+}
+
+// recordQuery doesn't treat sql.ErrNoRows as a failure, lookups of missing
+// rows are expected.
+func recordQuery(ctx context.Context, query string, err error) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(semconv.DBStatementKey.String(query))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
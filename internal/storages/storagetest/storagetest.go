@@ -0,0 +1,835 @@
+// Package storagetest is the conformance suite run against every storage,
+// so the backends stay interchangeable.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+)
+
+// Factory returns the storage under test. Storages may be shared between
+// tests, so the suite only relies on the data it creates itself.
+type Factory func(t *testing.T) storages.Storager
+
+var errRollback = errors.New("rollback")
+
+// Run runs the whole suite against the storage returned by newStorage.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storages.Storager)
+	}{
+		{"Users", testUsers},
+		{"Orders", testOrders},
+		{"OrderTransitions", testOrderTransitions},
+		{"OrdersPaging", testOrdersPaging},
+		{"Ledger", testLedger},
+		{"Withdrawals", testWithdrawals},
+		{"Transaction", testTransaction},
+		{"RefreshTokens", testRefreshTokens},
+		{"RevokedTokens", testRevokedTokens},
+		{"LoginAttempts", testLoginAttempts},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"OrderEvents", testOrderEvents},
+		{"OrderEventsCommitOrder", testOrderEventsCommitOrder},
+		{"Webhooks", testWebhooks},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantErr(t *testing.T, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("got error %v, want %v", err, target)
+	}
+}
+
+// orderNumber returns a number unused by earlier runs against the same database.
+func orderNumber() string {
+	return fmt.Sprintf("%d%09d", time.Now().UnixNano(), rand.Intn(1e9))
+}
+
+func addUser(t *testing.T, s storages.Storager) models.User {
+	t.Helper()
+	u := models.User{ID: uuid.NewString(), Login: "user-" + uuid.NewString(), Password: "hash"}
+	must(t, s.AddUser(context.Background(), u))
+	return u
+}
+
+func addOrder(t *testing.T, s storages.Storager, UID string) models.Order {
+	t.Helper()
+	order := models.Order{ID: orderNumber(), UID: UID, AccrualStatus: models.AccrualStatusNew}
+	must(t, s.AddOrder(context.Background(), order))
+	return order
+}
+
+func credit(t *testing.T, s storages.Storager, UID string, amount models.Money) string {
+	t.Helper()
+	ctx := context.Background()
+	orderID := orderNumber()
+	added, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryCredit, OrderID: orderID, Amount: amount})
+	must(t, err)
+	if !added {
+		t.Fatal("credit not added")
+	}
+	must(t, s.ReconcileBalanceByUID(ctx, UID))
+	return orderID
+}
+
+func testUsers(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	u := addUser(t, s)
+
+	wantErr(t, s.AddUser(ctx, models.User{ID: uuid.NewString(), Login: u.Login, Password: "other"}), models.ErrUserAlreadyExists)
+
+	got, err := s.GetUserByLogin(ctx, models.User{Login: u.Login})
+	must(t, err)
+	if got.ID != u.ID || got.Password != u.Password {
+		t.Fatalf("GetUserByLogin = %+v, want %+v", got, u)
+	}
+	if _, err = s.GetUserByLogin(ctx, models.User{Login: "missing-" + uuid.NewString()}); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("GetUserByLogin of unknown login: %v", err)
+	}
+	if _, err = s.GetUserByID(ctx, uuid.NewString()); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("GetUserByID of unknown user: %v", err)
+	}
+
+	must(t, s.UpdateUserPassword(ctx, u.ID, "new hash"))
+	got, err = s.GetUserByID(ctx, u.ID)
+	must(t, err)
+	if got.Password != "new hash" {
+		t.Fatalf("password = %q after update", got.Password)
+	}
+	wantErr(t, s.UpdateUserPassword(ctx, uuid.NewString(), "hash"), models.ErrUserNotFound)
+
+	must(t, s.AnonymizeUser(ctx, u.ID))
+	if _, err = s.GetUserByID(ctx, u.ID); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("GetUserByID of deleted user: %v", err)
+	}
+	wantErr(t, s.AnonymizeUser(ctx, u.ID), models.ErrUserNotFound)
+	// the login is free again
+	must(t, s.AddUser(ctx, models.User{ID: uuid.NewString(), Login: u.Login, Password: "hash"}))
+}
+
+func testOrders(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	owner, other := uuid.NewString(), uuid.NewString()
+	order := addOrder(t, s, owner)
+
+	wantErr(t, s.AddOrder(ctx, order), models.ErrOrderAlreadyExists)
+	wantErr(t, s.AddOrder(ctx, models.Order{ID: order.ID, UID: other, AccrualStatus: models.AccrualStatusNew}), models.ErrOrderBelongsAnotherUser)
+
+	got, err := s.GetOrderByID(ctx, order.ID)
+	must(t, err)
+	if got.UID != owner || got.AccrualStatus != models.AccrualStatusNew || got.Accrual != nil || got.UploadedAt.IsZero() {
+		t.Fatalf("GetOrderByID = %+v", got)
+	}
+	if _, err = s.GetOrderByID(ctx, orderNumber()); !errors.Is(err, models.ErrOrderNotFound) {
+		t.Fatalf("GetOrderByID of unknown order: %v", err)
+	}
+	if _, err = s.GetOrdersByUID(ctx, other, models.OrderFilter{}); !errors.Is(err, models.ErrNoOrders) {
+		t.Fatalf("GetOrdersByUID without orders: %v", err)
+	}
+
+	unfinished := func(ID string) bool {
+		orders, err := s.GetUnfinishedOrders(ctx)
+		must(t, err)
+		for _, o := range orders {
+			if o.ID == ID {
+				return true
+			}
+		}
+		return false
+	}
+	if !unfinished(order.ID) {
+		t.Fatal("new order is not unfinished")
+	}
+
+	accrual := models.Money(1050)
+	order.AccrualStatus, order.Accrual = models.AccrualStatusProcessed, &accrual
+	must(t, s.UpdateOrder(ctx, order))
+	got, err = s.GetOrderByID(ctx, order.ID)
+	must(t, err)
+	if got.AccrualStatus != models.AccrualStatusProcessed || got.Accrual == nil || *got.Accrual != accrual {
+		t.Fatalf("order after update = %+v", got)
+	}
+	if unfinished(order.ID) {
+		t.Fatal("processed order is unfinished")
+	}
+
+	order.AccrualStatus = models.AccrualStatusProcessing
+	wantErr(t, s.UpdateOrder(ctx, order), models.ErrInvalidStatusTransition)
+	wantErr(t, s.UpdateOrder(ctx, models.Order{ID: orderNumber(), AccrualStatus: models.AccrualStatusProcessing}), models.ErrInvalidStatusTransition)
+}
+
+// testOrderTransitions updates orders between every pair of statuses: the
+// storage must refuse the transitions the models refuse.
+func testOrderTransitions(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+	statuses := []models.AccrualStatus{models.AccrualStatusNew, models.AccrualStatusProcessing, models.AccrualStatusProcessed, models.AccrualStatusInvalid}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			order := addOrder(t, s, UID)
+			if from != models.AccrualStatusNew {
+				order.AccrualStatus = from
+				must(t, s.UpdateOrder(ctx, order))
+			}
+
+			order.AccrualStatus = to
+			err := s.UpdateOrder(ctx, order)
+			want := from
+			if from.CanTransitionTo(to) {
+				must(t, err)
+				want = to
+			} else {
+				wantErr(t, err, models.ErrInvalidStatusTransition)
+			}
+
+			got, err := s.GetOrderByID(ctx, order.ID)
+			must(t, err)
+			if got.AccrualStatus != want {
+				t.Fatalf("%s -> %s: stored status %s, want %s", from, to, got.AccrualStatus, want)
+			}
+		}
+	}
+}
+
+func testOrdersPaging(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+	for i := 0; i < 5; i++ {
+		addOrder(t, s, UID)
+	}
+	processed := addOrder(t, s, UID)
+	processed.AccrualStatus = models.AccrualStatusProcessed
+	must(t, s.UpdateOrder(ctx, processed))
+
+	for _, desc := range []bool{false, true} {
+		all, err := s.GetOrdersByUID(ctx, UID, models.OrderFilter{Page: models.Page{Desc: desc}})
+		must(t, err)
+		if len(all) != 6 {
+			t.Fatalf("desc=%v: got %d orders, want 6", desc, len(all))
+		}
+
+		var paged []models.Order
+		page := models.Page{Limit: 4, Desc: desc}
+		for {
+			orders, err := s.GetOrdersByUID(ctx, UID, models.OrderFilter{Page: page})
+			if errors.Is(err, models.ErrNoOrders) {
+				break
+			}
+			must(t, err)
+			if len(orders) > page.Limit {
+				t.Fatalf("desc=%v: got %d orders, limit %d", desc, len(orders), page.Limit)
+			}
+			paged = append(paged, orders...)
+			last := orders[len(orders)-1]
+			page.After = &models.Cursor{Time: last.UploadedAt, ID: last.ID}
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("desc=%v: pages hold %d orders, want %d", desc, len(paged), len(all))
+		}
+		for i := range all {
+			if paged[i].ID != all[i].ID {
+				t.Fatalf("desc=%v: order %d is %s in pages, %s in the list", desc, i, paged[i].ID, all[i].ID)
+			}
+			if i > 0 && page.Less(all[i].UploadedAt, all[i].ID, all[i-1].UploadedAt, all[i-1].ID) {
+				t.Fatalf("desc=%v: orders %d and %d are out of order", desc, i-1, i)
+			}
+		}
+	}
+
+	orders, err := s.GetOrdersByUID(ctx, UID, models.OrderFilter{Statuses: []models.AccrualStatus{models.AccrualStatusProcessed}})
+	must(t, err)
+	if len(orders) != 1 || orders[0].ID != processed.ID {
+		t.Fatalf("status filter returned %+v", orders)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if _, err = s.GetOrdersByUID(ctx, UID, models.OrderFilter{Page: models.Page{From: &future}}); !errors.Is(err, models.ErrNoOrders) {
+		t.Fatalf("orders uploaded after an hour: %v", err)
+	}
+}
+
+func testLedger(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+
+	if _, err := s.GetBalanceByUID(ctx, UID); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("GetBalanceByUID without balance: %v", err)
+	}
+	if _, err := s.GetCurrentBalanceByUID(ctx, UID); !errors.Is(err, models.ErrUserNotFound) {
+		t.Fatalf("GetCurrentBalanceByUID without balance: %v", err)
+	}
+
+	orderID := credit(t, s, UID, 1000)
+	added, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryCredit, OrderID: orderID, Amount: 1000})
+	must(t, err)
+	if added {
+		t.Fatal("the order was credited twice")
+	}
+	must(t, s.ReconcileBalanceByUID(ctx, UID))
+
+	balance, err := s.GetBalanceByUID(ctx, UID)
+	must(t, err)
+	if balance.Current != 1000 || balance.Withdrawn != 0 {
+		t.Fatalf("balance = %+v, want 10 current", balance)
+	}
+	// other users of a shared database change the total concurrently
+	total, err := s.GetTotalBalance(ctx)
+	must(t, err)
+	if total.Less(balance.Current) {
+		t.Fatalf("total balance %v is less than the user balance %v", total, balance.Current)
+	}
+
+	if _, err = s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryDebit, OrderID: orderNumber(), Amount: 0}); err == nil {
+		t.Fatal("zero ledger amount accepted")
+	}
+
+	// a debit beyond the balance fails the reconciliation
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryDebit, OrderID: orderNumber(), Amount: 1001}); err != nil {
+			return err
+		}
+		return s.ReconcileBalanceByUID(ctx, UID)
+	})
+	wantErr(t, err, models.ErrInsufficientFunds)
+
+	current, err := s.GetCurrentBalanceByUID(ctx, UID)
+	must(t, err)
+	if current != 1000 {
+		t.Fatalf("current balance = %v after the failed debit, want 10", current)
+	}
+}
+
+func testWithdrawals(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+	credit(t, s, UID, 1000)
+
+	if _, err := s.GetWithdrawalsByUID(ctx, UID, models.WithdrawalFilter{}); !errors.Is(err, models.ErrNoWithdrawals) {
+		t.Fatalf("GetWithdrawalsByUID without withdrawals: %v", err)
+	}
+
+	var withdrawn []string
+	processedAt := map[string]time.Time{}
+	for i := 0; i < 3; i++ {
+		w := models.Withdrawal{OrderID: orderNumber(), UID: UID, Amount: 100}
+		err := s.Transaction(ctx, func(ctx context.Context) error {
+			stored, err := s.AddWithdrawal(ctx, w)
+			if err != nil {
+				return err
+			}
+			if stored.OrderID != w.OrderID || stored.Amount != w.Amount || stored.ProcessedAt.IsZero() {
+				t.Errorf("AddWithdrawal = %+v", stored)
+			}
+			processedAt[w.OrderID] = stored.ProcessedAt
+			if _, err := s.AddLedgerEntry(ctx, models.LedgerEntry{UID: UID, Kind: models.LedgerEntryDebit, OrderID: w.OrderID, Amount: w.Amount}); err != nil {
+				return err
+			}
+			return s.ReconcileBalanceByUID(ctx, UID)
+		})
+		must(t, err)
+		withdrawn = append(withdrawn, w.OrderID)
+	}
+	_, err := s.AddWithdrawal(ctx, models.Withdrawal{OrderID: withdrawn[0], UID: UID, Amount: 100})
+	wantErr(t, err, models.ErrWithdrawalAlreadyExists)
+
+	balance, err := s.GetBalanceByUID(ctx, UID)
+	must(t, err)
+	if balance.Current != 700 || balance.Withdrawn != 300 {
+		t.Fatalf("balance = %+v, want 7 current and 3 withdrawn", balance)
+	}
+
+	all, err := s.GetWithdrawalsByUID(ctx, UID, models.WithdrawalFilter{})
+	must(t, err)
+	if len(all) != 3 {
+		t.Fatalf("got %d withdrawals, want 3", len(all))
+	}
+	for _, w := range all {
+		if !w.ProcessedAt.Equal(processedAt[w.OrderID]) {
+			t.Fatalf("withdrawal %s listed at %v, added at %v", w.OrderID, w.ProcessedAt, processedAt[w.OrderID])
+		}
+	}
+	page, err := s.GetWithdrawalsByUID(ctx, UID, models.WithdrawalFilter{Page: models.Page{Limit: 2}})
+	must(t, err)
+	if len(page) != 2 || page[0].OrderID != all[0].OrderID || page[1].OrderID != all[1].OrderID {
+		t.Fatalf("first page = %+v, list = %+v", page, all)
+	}
+	last := page[1]
+	rest, err := s.GetWithdrawalsByUID(ctx, UID, models.WithdrawalFilter{Page: models.Page{Limit: 2, After: &models.Cursor{Time: last.ProcessedAt, ID: last.OrderID}}})
+	must(t, err)
+	if len(rest) != 1 || rest[0].OrderID != all[2].OrderID {
+		t.Fatalf("second page = %+v, list = %+v", rest, all)
+	}
+}
+
+func testTransaction(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+
+	committed := models.Order{ID: orderNumber(), UID: UID, AccrualStatus: models.AccrualStatusNew}
+	must(t, s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.AddOrder(ctx, committed); err != nil {
+			return err
+		}
+		// reads with the transaction context see its writes
+		_, err := s.GetOrderByID(ctx, committed.ID)
+		return err
+	}))
+	if _, err := s.GetOrderByID(ctx, committed.ID); err != nil {
+		t.Fatalf("committed order: %v", err)
+	}
+
+	outer, inner := orderNumber(), orderNumber()
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.AddOrder(ctx, models.Order{ID: outer, UID: UID, AccrualStatus: models.AccrualStatusNew}); err != nil {
+			return err
+		}
+		// the nested transaction joins the outer one
+		if err := s.Transaction(ctx, func(ctx context.Context) error {
+			return s.AddOrder(ctx, models.Order{ID: inner, UID: UID, AccrualStatus: models.AccrualStatusNew})
+		}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	wantErr(t, err, errRollback)
+	for _, ID := range []string{outer, inner} {
+		if _, err = s.GetOrderByID(ctx, ID); !errors.Is(err, models.ErrOrderNotFound) {
+			t.Fatalf("order %s of the rolled back transaction: %v", ID, err)
+		}
+	}
+}
+
+func testRefreshTokens(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID, familyID := uuid.NewString(), uuid.NewString()
+	newToken := func(familyID string) models.RefreshToken {
+		token := models.RefreshToken{
+			ID:        uuid.NewString(),
+			FamilyID:  familyID,
+			UID:       UID,
+			TokenHash: uuid.NewString(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		must(t, s.AddRefreshToken(ctx, token))
+		return token
+	}
+	first, second, otherFamily := newToken(familyID), newToken(familyID), newToken(uuid.NewString())
+
+	got, err := s.GetRefreshTokenByHash(ctx, first.TokenHash)
+	must(t, err)
+	if got.ID != first.ID || got.FamilyID != familyID || got.IsRevoked() {
+		t.Fatalf("GetRefreshTokenByHash = %+v", got)
+	}
+	if _, err = s.GetRefreshTokenByHash(ctx, uuid.NewString()); !errors.Is(err, models.ErrInvalidRefreshToken) {
+		t.Fatalf("GetRefreshTokenByHash of unknown token: %v", err)
+	}
+
+	must(t, s.RevokeRefreshToken(ctx, first.ID, &second.ID))
+	got, err = s.GetRefreshTokenByHash(ctx, first.TokenHash)
+	must(t, err)
+	if !got.IsRevoked() || got.ReplacedBy == nil || *got.ReplacedBy != second.ID {
+		t.Fatalf("rotated token = %+v", got)
+	}
+
+	revoked := func(token models.RefreshToken) bool {
+		got, err := s.GetRefreshTokenByHash(ctx, token.TokenHash)
+		must(t, err)
+		return got.IsRevoked()
+	}
+	must(t, s.RevokeRefreshTokenFamily(ctx, familyID))
+	if !revoked(second) || revoked(otherFamily) {
+		t.Fatal("RevokeRefreshTokenFamily revoked the wrong tokens")
+	}
+	must(t, s.RevokeRefreshTokensByUID(ctx, UID))
+	if !revoked(otherFamily) {
+		t.Fatal("RevokeRefreshTokensByUID left a token")
+	}
+}
+
+func testRevokedTokens(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	u := addUser(t, s)
+	jti := uuid.NewString()
+	got, err := s.GetUserByID(ctx, u.ID)
+	must(t, err)
+	generation := got.TokenGeneration
+
+	revoked, err := s.IsTokenRevoked(ctx, jti, u.ID, generation)
+	must(t, err)
+	if revoked {
+		t.Fatal("fresh token is revoked")
+	}
+
+	must(t, s.AddRevokedToken(ctx, jti, time.Now().Add(time.Hour)))
+	revoked, err = s.IsTokenRevoked(ctx, jti, u.ID, generation)
+	must(t, err)
+	if !revoked {
+		t.Fatal("denylisted token is not revoked")
+	}
+
+	// tokens issued in the same second as the password change, before and
+	// after it, are told apart by the generation
+	must(t, s.UpdateUserPassword(ctx, u.ID, "new hash"))
+	revoked, err = s.IsTokenRevoked(ctx, uuid.NewString(), u.ID, generation)
+	must(t, err)
+	if !revoked {
+		t.Fatal("token issued before the password change is not revoked")
+	}
+	got, err = s.GetUserByID(ctx, u.ID)
+	must(t, err)
+	if got.TokenGeneration <= generation {
+		t.Fatalf("token generation %d after the password change, was %d", got.TokenGeneration, generation)
+	}
+	revoked, err = s.IsTokenRevoked(ctx, uuid.NewString(), u.ID, got.TokenGeneration)
+	must(t, err)
+	if revoked {
+		t.Fatal("token issued after the password change is revoked")
+	}
+	byLogin, err := s.GetUserByLogin(ctx, models.User{Login: u.Login})
+	must(t, err)
+	if byLogin.TokenGeneration != got.TokenGeneration {
+		t.Fatalf("token generation by login %d, by ID %d", byLogin.TokenGeneration, got.TokenGeneration)
+	}
+
+	must(t, s.AnonymizeUser(ctx, u.ID))
+	revoked, err = s.IsTokenRevoked(ctx, uuid.NewString(), u.ID, got.TokenGeneration)
+	must(t, err)
+	if !revoked {
+		t.Fatal("token of a deleted user is not revoked")
+	}
+}
+
+func testLoginAttempts(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	key := "login:" + uuid.NewString()
+
+	attempt, err := s.GetLoginAttempt(ctx, key)
+	must(t, err)
+	if attempt.Key != key || attempt.Failures != 0 || attempt.LockedFor() != 0 {
+		t.Fatalf("unknown attempt = %+v", attempt)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if attempt, err = s.AddLoginFailure(ctx, key, time.Hour); err != nil || attempt.Failures != i {
+			t.Fatalf("failure %d: %+v, %v", i, attempt, err)
+		}
+	}
+
+	must(t, s.LockLogin(ctx, key, time.Now().Add(time.Hour)))
+	attempt, err = s.GetLoginAttempt(ctx, key)
+	must(t, err)
+	if attempt.Failures != 3 || attempt.LockedFor() <= 0 {
+		t.Fatalf("locked attempt = %+v", attempt)
+	}
+
+	must(t, s.ResetLoginAttempts(ctx, key))
+	attempt, err = s.GetLoginAttempt(ctx, key)
+	must(t, err)
+	if attempt.Failures != 0 || attempt.LockedFor() != 0 {
+		t.Fatalf("reset attempt = %+v", attempt)
+	}
+
+	// failures older than the window are forgotten
+	_, err = s.AddLoginFailure(ctx, key, time.Millisecond)
+	must(t, err)
+	time.Sleep(10 * time.Millisecond)
+	if attempt, err = s.AddLoginFailure(ctx, key, time.Millisecond); err != nil || attempt.Failures != 1 {
+		t.Fatalf("failure after the window: %+v, %v", attempt, err)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+	key := models.IdempotencyKey{UID: UID, Key: uuid.NewString(), Fingerprint: "first", ExpiresAt: time.Now().Add(time.Hour)}
+
+	if _, err := s.GetIdempotencyKey(ctx, UID, key.Key); !errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey of unknown key: %v", err)
+	}
+
+	added, err := s.AddIdempotencyKey(ctx, key)
+	must(t, err)
+	if !added {
+		t.Fatal("new key not added")
+	}
+	again := key
+	again.Fingerprint = "second"
+	if added, err = s.AddIdempotencyKey(ctx, again); err != nil || added {
+		t.Fatalf("key added twice: %v, %v", added, err)
+	}
+
+	got, err := s.GetIdempotencyKey(ctx, UID, key.Key)
+	must(t, err)
+	if got.Fingerprint != "first" || got.IsCompleted() {
+		t.Fatalf("GetIdempotencyKey = %+v", got)
+	}
+
+	status := 200
+	// a response to another request with the key is not stored
+	again.StatusCode = &status
+	must(t, s.SaveIdempotencyKeyResponse(ctx, again))
+	if got, err = s.GetIdempotencyKey(ctx, UID, key.Key); err != nil || got.IsCompleted() {
+		t.Fatalf("key completed by another request: %+v, %v", got, err)
+	}
+
+	key.StatusCode, key.ContentType, key.Body = &status, "application/json", []byte(`{}`)
+	key.ExpiresAt = time.Now().Add(24 * time.Hour)
+	must(t, s.SaveIdempotencyKeyResponse(ctx, key))
+	got, err = s.GetIdempotencyKey(ctx, UID, key.Key)
+	must(t, err)
+	if !got.IsCompleted() || *got.StatusCode != status || got.ContentType != "application/json" || string(got.Body) != `{}` {
+		t.Fatalf("completed key = %+v", got)
+	}
+	if !got.ExpiresAt.After(time.Now().Add(23 * time.Hour)) {
+		t.Fatalf("completed key expires at %v, want the extended expiry", got.ExpiresAt)
+	}
+
+	must(t, s.DeleteIdempotencyKey(ctx, UID, key.Key))
+	if _, err = s.GetIdempotencyKey(ctx, UID, key.Key); !errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey of deleted key: %v", err)
+	}
+
+	expired := models.IdempotencyKey{UID: UID, Key: uuid.NewString(), Fingerprint: "first", ExpiresAt: time.Now().Add(-time.Second)}
+	_, err = s.AddIdempotencyKey(ctx, expired)
+	must(t, err)
+	if _, err = s.GetIdempotencyKey(ctx, UID, expired.Key); !errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey of expired key: %v", err)
+	}
+	// an expired key is reused
+	expired.ExpiresAt = time.Now().Add(time.Hour)
+	if added, err = s.AddIdempotencyKey(ctx, expired); err != nil || !added {
+		t.Fatalf("expired key not reused: %v, %v", added, err)
+	}
+	must(t, s.DeleteExpiredIdempotencyKeys(ctx))
+	if _, err = s.GetIdempotencyKey(ctx, UID, expired.Key); err != nil {
+		t.Fatalf("live key deleted as expired: %v", err)
+	}
+}
+
+func testOrderEvents(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID, other := uuid.NewString(), uuid.NewString()
+	accrual := models.Money(500)
+
+	var events []models.OrderEvent
+	for _, e := range []models.OrderEvent{
+		{UID: UID, OrderID: orderNumber(), Status: models.AccrualStatusProcessing},
+		{UID: other, OrderID: orderNumber(), Status: models.AccrualStatusProcessing},
+		{UID: UID, OrderID: orderNumber(), Status: models.AccrualStatusProcessed, Accrual: &accrual},
+	} {
+		res, err := s.AddOrderEvent(ctx, e)
+		must(t, err)
+		if res.OrderID != e.OrderID || res.CreatedAt.IsZero() {
+			t.Fatalf("AddOrderEvent = %+v", res)
+		}
+		if len(events) > 0 && res.ID <= events[len(events)-1].ID {
+			t.Fatalf("event ID %d after %d", res.ID, events[len(events)-1].ID)
+		}
+		events = append(events, res)
+	}
+
+	got, err := s.GetOrderEventsByUID(ctx, UID, 0, 10)
+	must(t, err)
+	if len(got) != 2 || got[0].ID != events[0].ID || got[1].ID != events[2].ID {
+		t.Fatalf("GetOrderEventsByUID = %+v", got)
+	}
+	if got[1].Accrual == nil || *got[1].Accrual != accrual || got[1].Status != models.AccrualStatusProcessed {
+		t.Fatalf("event = %+v", got[1])
+	}
+
+	if got, err = s.GetOrderEventsByUID(ctx, UID, events[0].ID, 10); err != nil || len(got) != 1 || got[0].ID != events[2].ID {
+		t.Fatalf("events after the first: %+v, %v", got, err)
+	}
+	if got, err = s.GetOrderEventsByUID(ctx, UID, 0, 1); err != nil || len(got) != 1 || got[0].ID != events[0].ID {
+		t.Fatalf("events limited to one: %+v, %v", got, err)
+	}
+
+	must(t, s.DeleteOrderEventsBefore(ctx, time.Now().Add(-time.Hour)))
+	if got, err = s.GetOrderEventsByUID(ctx, UID, 0, 10); err != nil || len(got) != 2 {
+		t.Fatalf("recent events deleted: %+v, %v", got, err)
+	}
+}
+
+// testOrderEventsCommitOrder checks that an event is not committed ahead of
+// an earlier event of the user, which a stream resuming after it would skip.
+func testOrderEventsCommitOrder(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+
+	type result struct {
+		event models.OrderEvent
+		err   error
+	}
+	added, release := make(chan models.OrderEvent), make(chan struct{})
+	first := make(chan error)
+	go func() {
+		first <- s.Transaction(ctx, func(ctx context.Context) error {
+			event, err := s.AddOrderEvent(ctx, models.OrderEvent{UID: UID, OrderID: orderNumber(), Status: models.AccrualStatusProcessing})
+			if err != nil {
+				return err
+			}
+			added <- event
+			<-release
+			return nil
+		})
+	}()
+	var firstEvent models.OrderEvent
+	select {
+	case firstEvent = <-added:
+	case err := <-first:
+		t.Fatalf("first event: %v", err)
+	}
+
+	second := make(chan result, 1)
+	go func() {
+		event, err := s.AddOrderEvent(ctx, models.OrderEvent{UID: UID, OrderID: orderNumber(), Status: models.AccrualStatusProcessed})
+		second <- result{event, err}
+	}()
+	select {
+	case res := <-second:
+		must(t, res.err)
+		if res.event.ID > firstEvent.ID {
+			t.Fatalf("event %d committed while the earlier event %d is not", res.event.ID, firstEvent.ID)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	must(t, <-first)
+	var secondEvent models.OrderEvent
+	select {
+	case res := <-second:
+		must(t, res.err)
+		secondEvent = res.event
+	case <-time.After(5 * time.Second):
+		t.Fatal("second event not added")
+	}
+
+	if secondEvent.ID <= firstEvent.ID {
+		t.Fatalf("event %d added after the event %d", secondEvent.ID, firstEvent.ID)
+	}
+	events, err := s.GetOrderEventsByUID(ctx, UID, firstEvent.ID, 10)
+	must(t, err)
+	if len(events) != 1 || events[0].ID != secondEvent.ID {
+		t.Fatalf("events after the first = %+v", events)
+	}
+}
+
+func testWebhooks(t *testing.T, s storages.Storager) {
+	ctx := context.Background()
+	UID := uuid.NewString()
+	webhook := models.Webhook{ID: uuid.NewString(), UID: UID, URL: "https://example.com/hook", Secret: "0123456789abcdef"}
+	must(t, s.AddWebhook(ctx, webhook))
+
+	got, err := s.GetWebhookByID(ctx, webhook.ID)
+	must(t, err)
+	if got.UID != UID || got.URL != webhook.URL || got.Secret != webhook.Secret {
+		t.Fatalf("GetWebhookByID = %+v", got)
+	}
+	if _, err = s.GetWebhookByID(ctx, uuid.NewString()); !errors.Is(err, models.ErrWebhookNotFound) {
+		t.Fatalf("GetWebhookByID of unknown webhook: %v", err)
+	}
+	webhooks, err := s.GetWebhooksByUID(ctx, UID)
+	must(t, err)
+	if len(webhooks) != 1 || webhooks[0].ID != webhook.ID {
+		t.Fatalf("GetWebhooksByUID = %+v", webhooks)
+	}
+
+	delivery := models.WebhookDelivery{
+		ID:        uuid.NewString(),
+		WebhookID: webhook.ID,
+		UID:       UID,
+		EventType: models.WebhookEventOrderUpdated,
+		Payload:   models.RawJSON(`{"number":"1"}`),
+		Status:    models.WebhookDeliveryPending,
+	}
+	must(t, s.AddWebhookDelivery(ctx, delivery))
+
+	claimed := func() bool {
+		deliveries, err := s.ClaimWebhookDeliveries(ctx, 1000, time.Hour)
+		must(t, err)
+		for _, d := range deliveries {
+			if d.ID == delivery.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if !claimed() {
+		t.Fatal("due delivery not claimed")
+	}
+	if claimed() {
+		t.Fatal("leased delivery claimed again")
+	}
+
+	code, now := 200, time.Now()
+	delivery.Status, delivery.Attempts, delivery.LastStatusCode, delivery.DeliveredAt = models.WebhookDeliveryDelivered, 1, &code, &now
+	delivery.NextAttemptAt = now
+	must(t, s.UpdateWebhookDelivery(ctx, delivery))
+	gotDelivery, err := s.GetWebhookDeliveryByID(ctx, delivery.ID)
+	must(t, err)
+	if gotDelivery.Status != models.WebhookDeliveryDelivered || gotDelivery.Attempts != 1 ||
+		gotDelivery.LastStatusCode == nil || *gotDelivery.LastStatusCode != code || gotDelivery.DeliveredAt == nil {
+		t.Fatalf("updated delivery = %+v", gotDelivery)
+	}
+	if claimed() {
+		t.Fatal("delivered delivery claimed")
+	}
+
+	deliveries, err := s.GetWebhookDeliveriesByWebhookID(ctx, webhook.ID, 10)
+	must(t, err)
+	if len(deliveries) != 1 || deliveries[0].ID != delivery.ID {
+		t.Fatalf("GetWebhookDeliveriesByWebhookID = %+v", deliveries)
+	}
+
+	pending := models.WebhookDelivery{
+		ID:        uuid.NewString(),
+		WebhookID: webhook.ID,
+		UID:       UID,
+		EventType: models.WebhookEventOrderUpdated,
+		Payload:   models.RawJSON(`{"number":"2"}`),
+		Status:    models.WebhookDeliveryPending,
+	}
+	must(t, s.AddWebhookDelivery(ctx, pending))
+	must(t, s.DeleteWebhookDeliveriesBefore(ctx, time.Now().Add(-time.Hour)))
+	if _, err = s.GetWebhookDeliveryByID(ctx, delivery.ID); err != nil {
+		t.Fatalf("recent delivery deleted: %v", err)
+	}
+	must(t, s.DeleteWebhookDeliveriesBefore(ctx, time.Now().Add(time.Second)))
+	if _, err = s.GetWebhookDeliveryByID(ctx, delivery.ID); !errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+		t.Fatalf("old delivered delivery: %v", err)
+	}
+	if _, err = s.GetWebhookDeliveryByID(ctx, pending.ID); err != nil {
+		t.Fatalf("pending delivery deleted: %v", err)
+	}
+
+	wantErr(t, s.DeleteWebhook(ctx, uuid.NewString(), webhook.ID), models.ErrWebhookNotFound)
+	must(t, s.DeleteWebhook(ctx, UID, webhook.ID))
+	if _, err = s.GetWebhookDeliveryByID(ctx, pending.ID); !errors.Is(err, models.ErrWebhookDeliveryNotFound) {
+		t.Fatalf("delivery of deleted webhook: %v", err)
+	}
+}
@@ -0,0 +1,1461 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/storages/interface.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/stsg/gophermart2/internal/models"
+)
+
+// MockStorager is a mock of Storager interface.
+type MockStorager struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoragerMockRecorder
+}
+
+// MockStoragerMockRecorder is the mock recorder for MockStorager.
+type MockStoragerMockRecorder struct {
+	mock *MockStorager
+}
+
+// NewMockStorager creates a new mock instance.
+func NewMockStorager(ctrl *gomock.Controller) *MockStorager {
+	mock := &MockStorager{ctrl: ctrl}
+	mock.recorder = &MockStoragerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorager) EXPECT() *MockStoragerMockRecorder {
+	return m.recorder
+}
+
+// AddIdempotencyKey mocks base method.
+func (m *MockStorager) AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddIdempotencyKey indicates an expected call of AddIdempotencyKey.
+func (mr *MockStoragerMockRecorder) AddIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIdempotencyKey", reflect.TypeOf((*MockStorager)(nil).AddIdempotencyKey), ctx, key)
+}
+
+// AddLedgerEntry mocks base method.
+func (m *MockStorager) AddLedgerEntry(ctx context.Context, entry models.LedgerEntry) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLedgerEntry", ctx, entry)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLedgerEntry indicates an expected call of AddLedgerEntry.
+func (mr *MockStoragerMockRecorder) AddLedgerEntry(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLedgerEntry", reflect.TypeOf((*MockStorager)(nil).AddLedgerEntry), ctx, entry)
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockStorager) AddLoginFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, key, window)
+	ret0, _ := ret[0].(models.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockStoragerMockRecorder) AddLoginFailure(ctx, key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockStorager)(nil).AddLoginFailure), ctx, key, window)
+}
+
+// AddOrder mocks base method.
+func (m *MockStorager) AddOrder(ctx context.Context, OrderID models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrder", ctx, OrderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrder indicates an expected call of AddOrder.
+func (mr *MockStoragerMockRecorder) AddOrder(ctx, OrderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockStorager)(nil).AddOrder), ctx, OrderID)
+}
+
+// AddOrderEvent mocks base method.
+func (m *MockStorager) AddOrderEvent(ctx context.Context, event models.OrderEvent) (models.OrderEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrderEvent", ctx, event)
+	ret0, _ := ret[0].(models.OrderEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddOrderEvent indicates an expected call of AddOrderEvent.
+func (mr *MockStoragerMockRecorder) AddOrderEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrderEvent", reflect.TypeOf((*MockStorager)(nil).AddOrderEvent), ctx, event)
+}
+
+// AddRefreshToken mocks base method.
+func (m *MockStorager) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRefreshToken indicates an expected call of AddRefreshToken.
+func (mr *MockStoragerMockRecorder) AddRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefreshToken", reflect.TypeOf((*MockStorager)(nil).AddRefreshToken), ctx, token)
+}
+
+// AddRevokedToken mocks base method.
+func (m *MockStorager) AddRevokedToken(ctx context.Context, JTI string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRevokedToken", ctx, JTI, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRevokedToken indicates an expected call of AddRevokedToken.
+func (mr *MockStoragerMockRecorder) AddRevokedToken(ctx, JTI, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRevokedToken", reflect.TypeOf((*MockStorager)(nil).AddRevokedToken), ctx, JTI, expiresAt)
+}
+
+// AddUser mocks base method.
+func (m *MockStorager) AddUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockStoragerMockRecorder) AddUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockStorager)(nil).AddUser), ctx, user)
+}
+
+// AddWebhook mocks base method.
+func (m *MockStorager) AddWebhook(ctx context.Context, webhook models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhook", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhook indicates an expected call of AddWebhook.
+func (mr *MockStoragerMockRecorder) AddWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhook", reflect.TypeOf((*MockStorager)(nil).AddWebhook), ctx, webhook)
+}
+
+// AddWebhookDelivery mocks base method.
+func (m *MockStorager) AddWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhookDelivery indicates an expected call of AddWebhookDelivery.
+func (mr *MockStoragerMockRecorder) AddWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhookDelivery", reflect.TypeOf((*MockStorager)(nil).AddWebhookDelivery), ctx, delivery)
+}
+
+// AddWithdrawal mocks base method.
+func (m *MockStorager) AddWithdrawal(ctx context.Context, wth models.Withdrawal) (models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWithdrawal", ctx, wth)
+	ret0, _ := ret[0].(models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWithdrawal indicates an expected call of AddWithdrawal.
+func (mr *MockStoragerMockRecorder) AddWithdrawal(ctx, wth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWithdrawal", reflect.TypeOf((*MockStorager)(nil).AddWithdrawal), ctx, wth)
+}
+
+// AnonymizeUser mocks base method.
+func (m *MockStorager) AnonymizeUser(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockStoragerMockRecorder) AnonymizeUser(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockStorager)(nil).AnonymizeUser), ctx, ID)
+}
+
+// CheckMigrations mocks base method.
+func (m *MockStorager) CheckMigrations(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckMigrations", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckMigrations indicates an expected call of CheckMigrations.
+func (mr *MockStoragerMockRecorder) CheckMigrations(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckMigrations", reflect.TypeOf((*MockStorager)(nil).CheckMigrations), ctx)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStorager) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, limit, lease)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStoragerMockRecorder) ClaimWebhookDeliveries(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStorager)(nil).ClaimWebhookDeliveries), ctx, limit, lease)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockStorager) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockStoragerMockRecorder) DeleteExpiredIdempotencyKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockStorager)(nil).DeleteExpiredIdempotencyKeys), ctx)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStorager) DeleteIdempotencyKey(ctx context.Context, UID, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, UID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStoragerMockRecorder) DeleteIdempotencyKey(ctx, UID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStorager)(nil).DeleteIdempotencyKey), ctx, UID, key)
+}
+
+// DeleteOrderEventsBefore mocks base method.
+func (m *MockStorager) DeleteOrderEventsBefore(ctx context.Context, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrderEventsBefore", ctx, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrderEventsBefore indicates an expected call of DeleteOrderEventsBefore.
+func (mr *MockStoragerMockRecorder) DeleteOrderEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrderEventsBefore", reflect.TypeOf((*MockStorager)(nil).DeleteOrderEventsBefore), ctx, before)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStorager) DeleteWebhook(ctx context.Context, UID, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, UID, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStoragerMockRecorder) DeleteWebhook(ctx, UID, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStorager)(nil).DeleteWebhook), ctx, UID, ID)
+}
+
+// DeleteWebhookDeliveriesBefore mocks base method.
+func (m *MockStorager) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookDeliveriesBefore", ctx, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookDeliveriesBefore indicates an expected call of DeleteWebhookDeliveriesBefore.
+func (mr *MockStoragerMockRecorder) DeleteWebhookDeliveriesBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookDeliveriesBefore", reflect.TypeOf((*MockStorager)(nil).DeleteWebhookDeliveriesBefore), ctx, before)
+}
+
+// GetBalanceByUID mocks base method.
+func (m *MockStorager) GetBalanceByUID(ctx context.Context, UID string) (models.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(models.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceByUID indicates an expected call of GetBalanceByUID.
+func (mr *MockStoragerMockRecorder) GetBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceByUID", reflect.TypeOf((*MockStorager)(nil).GetBalanceByUID), ctx, UID)
+}
+
+// GetCurrentBalanceByUID mocks base method.
+func (m *MockStorager) GetCurrentBalanceByUID(ctx context.Context, UID string) (models.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(models.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentBalanceByUID indicates an expected call of GetCurrentBalanceByUID.
+func (mr *MockStoragerMockRecorder) GetCurrentBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentBalanceByUID", reflect.TypeOf((*MockStorager)(nil).GetCurrentBalanceByUID), ctx, UID)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStorager) GetIdempotencyKey(ctx context.Context, UID, key string) (models.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, UID, key)
+	ret0, _ := ret[0].(models.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoragerMockRecorder) GetIdempotencyKey(ctx, UID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStorager)(nil).GetIdempotencyKey), ctx, UID, key)
+}
+
+// GetLoginAttempt mocks base method.
+func (m *MockStorager) GetLoginAttempt(ctx context.Context, key string) (models.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(models.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempt indicates an expected call of GetLoginAttempt.
+func (mr *MockStoragerMockRecorder) GetLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempt", reflect.TypeOf((*MockStorager)(nil).GetLoginAttempt), ctx, key)
+}
+
+// GetOrderByID mocks base method.
+func (m *MockStorager) GetOrderByID(ctx context.Context, ID string) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, ID)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockStoragerMockRecorder) GetOrderByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockStorager)(nil).GetOrderByID), ctx, ID)
+}
+
+// GetOrderEventsByUID mocks base method.
+func (m *MockStorager) GetOrderEventsByUID(ctx context.Context, UID string, afterID int64, limit int) ([]models.OrderEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderEventsByUID", ctx, UID, afterID, limit)
+	ret0, _ := ret[0].([]models.OrderEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderEventsByUID indicates an expected call of GetOrderEventsByUID.
+func (mr *MockStoragerMockRecorder) GetOrderEventsByUID(ctx, UID, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderEventsByUID", reflect.TypeOf((*MockStorager)(nil).GetOrderEventsByUID), ctx, UID, afterID, limit)
+}
+
+// GetOrdersByUID mocks base method.
+func (m *MockStorager) GetOrdersByUID(ctx context.Context, UID string, filter models.OrderFilter) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByUID", ctx, UID, filter)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByUID indicates an expected call of GetOrdersByUID.
+func (mr *MockStoragerMockRecorder) GetOrdersByUID(ctx, UID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUID", reflect.TypeOf((*MockStorager)(nil).GetOrdersByUID), ctx, UID, filter)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockStorager) GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", ctx, hash)
+	ret0, _ := ret[0].(models.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockStoragerMockRecorder) GetRefreshTokenByHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockStorager)(nil).GetRefreshTokenByHash), ctx, hash)
+}
+
+// GetTotalBalance mocks base method.
+func (m *MockStorager) GetTotalBalance(ctx context.Context) (models.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalBalance", ctx)
+	ret0, _ := ret[0].(models.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalBalance indicates an expected call of GetTotalBalance.
+func (mr *MockStoragerMockRecorder) GetTotalBalance(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalBalance", reflect.TypeOf((*MockStorager)(nil).GetTotalBalance), ctx)
+}
+
+// GetUnfinishedOrders mocks base method.
+func (m *MockStorager) GetUnfinishedOrders(ctx context.Context) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnfinishedOrders", ctx)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnfinishedOrders indicates an expected call of GetUnfinishedOrders.
+func (mr *MockStoragerMockRecorder) GetUnfinishedOrders(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnfinishedOrders", reflect.TypeOf((*MockStorager)(nil).GetUnfinishedOrders), ctx)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStorager) GetUserByID(ctx context.Context, ID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, ID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStoragerMockRecorder) GetUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStorager)(nil).GetUserByID), ctx, ID)
+}
+
+// GetUserByLogin mocks base method.
+func (m *MockStorager) GetUserByLogin(ctx context.Context, user models.User) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, user)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin.
+func (mr *MockStoragerMockRecorder) GetUserByLogin(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockStorager)(nil).GetUserByLogin), ctx, user)
+}
+
+// GetWebhookByID mocks base method.
+func (m *MockStorager) GetWebhookByID(ctx context.Context, ID string) (models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookByID", ctx, ID)
+	ret0, _ := ret[0].(models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookByID indicates an expected call of GetWebhookByID.
+func (mr *MockStoragerMockRecorder) GetWebhookByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookByID", reflect.TypeOf((*MockStorager)(nil).GetWebhookByID), ctx, ID)
+}
+
+// GetWebhookDeliveriesByWebhookID mocks base method.
+func (m *MockStorager) GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveriesByWebhookID", ctx, webhookID, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveriesByWebhookID indicates an expected call of GetWebhookDeliveriesByWebhookID.
+func (mr *MockStoragerMockRecorder) GetWebhookDeliveriesByWebhookID(ctx, webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveriesByWebhookID", reflect.TypeOf((*MockStorager)(nil).GetWebhookDeliveriesByWebhookID), ctx, webhookID, limit)
+}
+
+// GetWebhookDeliveryByID mocks base method.
+func (m *MockStorager) GetWebhookDeliveryByID(ctx context.Context, ID string) (models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveryByID", ctx, ID)
+	ret0, _ := ret[0].(models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveryByID indicates an expected call of GetWebhookDeliveryByID.
+func (mr *MockStoragerMockRecorder) GetWebhookDeliveryByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveryByID", reflect.TypeOf((*MockStorager)(nil).GetWebhookDeliveryByID), ctx, ID)
+}
+
+// GetWebhooksByUID mocks base method.
+func (m *MockStorager) GetWebhooksByUID(ctx context.Context, UID string) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooksByUID", ctx, UID)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooksByUID indicates an expected call of GetWebhooksByUID.
+func (mr *MockStoragerMockRecorder) GetWebhooksByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooksByUID", reflect.TypeOf((*MockStorager)(nil).GetWebhooksByUID), ctx, UID)
+}
+
+// GetWithdrawalsByUID mocks base method.
+func (m *MockStorager) GetWithdrawalsByUID(ctx context.Context, UID string, filter models.WithdrawalFilter) ([]models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalsByUID", ctx, UID, filter)
+	ret0, _ := ret[0].([]models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalsByUID indicates an expected call of GetWithdrawalsByUID.
+func (mr *MockStoragerMockRecorder) GetWithdrawalsByUID(ctx, UID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsByUID", reflect.TypeOf((*MockStorager)(nil).GetWithdrawalsByUID), ctx, UID, filter)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStorager) IsTokenRevoked(ctx context.Context, JTI, UID string, generation int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, JTI, UID, generation)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStoragerMockRecorder) IsTokenRevoked(ctx, JTI, UID, generation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStorager)(nil).IsTokenRevoked), ctx, JTI, UID, generation)
+}
+
+// LockLogin mocks base method.
+func (m *MockStorager) LockLogin(ctx context.Context, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockStoragerMockRecorder) LockLogin(ctx, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockStorager)(nil).LockLogin), ctx, key, until)
+}
+
+// Ping mocks base method.
+func (m *MockStorager) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoragerMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorager)(nil).Ping), ctx)
+}
+
+// ReconcileBalanceByUID mocks base method.
+func (m *MockStorager) ReconcileBalanceByUID(ctx context.Context, UID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileBalanceByUID indicates an expected call of ReconcileBalanceByUID.
+func (mr *MockStoragerMockRecorder) ReconcileBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileBalanceByUID", reflect.TypeOf((*MockStorager)(nil).ReconcileBalanceByUID), ctx, UID)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockStorager) ResetLoginAttempts(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockStoragerMockRecorder) ResetLoginAttempts(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockStorager)(nil).ResetLoginAttempts), ctx, key)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockStorager) RevokeRefreshToken(ctx context.Context, ID string, replacedBy *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, ID, replacedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockStoragerMockRecorder) RevokeRefreshToken(ctx, ID, replacedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockStorager)(nil).RevokeRefreshToken), ctx, ID, replacedBy)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockStorager) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockStoragerMockRecorder) RevokeRefreshTokenFamily(ctx, familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockStorager)(nil).RevokeRefreshTokenFamily), ctx, familyID)
+}
+
+// RevokeRefreshTokensByUID mocks base method.
+func (m *MockStorager) RevokeRefreshTokensByUID(ctx context.Context, UID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokensByUID", ctx, UID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokensByUID indicates an expected call of RevokeRefreshTokensByUID.
+func (mr *MockStoragerMockRecorder) RevokeRefreshTokensByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokensByUID", reflect.TypeOf((*MockStorager)(nil).RevokeRefreshTokensByUID), ctx, UID)
+}
+
+// SaveIdempotencyKeyResponse mocks base method.
+func (m *MockStorager) SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotencyKeyResponse", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotencyKeyResponse indicates an expected call of SaveIdempotencyKeyResponse.
+func (mr *MockStoragerMockRecorder) SaveIdempotencyKeyResponse(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotencyKeyResponse", reflect.TypeOf((*MockStorager)(nil).SaveIdempotencyKeyResponse), ctx, key)
+}
+
+// Transaction mocks base method.
+func (m *MockStorager) Transaction(ctx context.Context, f func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", ctx, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockStoragerMockRecorder) Transaction(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockStorager)(nil).Transaction), ctx, f)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockStorager) UpdateOrder(ctx context.Context, order models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockStoragerMockRecorder) UpdateOrder(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockStorager)(nil).UpdateOrder), ctx, order)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockStorager) UpdateUserPassword(ctx context.Context, ID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, ID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStoragerMockRecorder) UpdateUserPassword(ctx, ID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStorager)(nil).UpdateUserPassword), ctx, ID, password)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockStorager) UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockStoragerMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockStorager)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
+
+// MockStorageReader is a mock of StorageReader interface.
+type MockStorageReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageReaderMockRecorder
+}
+
+// MockStorageReaderMockRecorder is the mock recorder for MockStorageReader.
+type MockStorageReaderMockRecorder struct {
+	mock *MockStorageReader
+}
+
+// NewMockStorageReader creates a new mock instance.
+func NewMockStorageReader(ctrl *gomock.Controller) *MockStorageReader {
+	mock := &MockStorageReader{ctrl: ctrl}
+	mock.recorder = &MockStorageReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageReader) EXPECT() *MockStorageReaderMockRecorder {
+	return m.recorder
+}
+
+// GetBalanceByUID mocks base method.
+func (m *MockStorageReader) GetBalanceByUID(ctx context.Context, UID string) (models.Balance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(models.Balance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceByUID indicates an expected call of GetBalanceByUID.
+func (mr *MockStorageReaderMockRecorder) GetBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceByUID", reflect.TypeOf((*MockStorageReader)(nil).GetBalanceByUID), ctx, UID)
+}
+
+// GetCurrentBalanceByUID mocks base method.
+func (m *MockStorageReader) GetCurrentBalanceByUID(ctx context.Context, UID string) (models.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrentBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(models.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrentBalanceByUID indicates an expected call of GetCurrentBalanceByUID.
+func (mr *MockStorageReaderMockRecorder) GetCurrentBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentBalanceByUID", reflect.TypeOf((*MockStorageReader)(nil).GetCurrentBalanceByUID), ctx, UID)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStorageReader) GetIdempotencyKey(ctx context.Context, UID, key string) (models.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, UID, key)
+	ret0, _ := ret[0].(models.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStorageReaderMockRecorder) GetIdempotencyKey(ctx, UID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStorageReader)(nil).GetIdempotencyKey), ctx, UID, key)
+}
+
+// GetLoginAttempt mocks base method.
+func (m *MockStorageReader) GetLoginAttempt(ctx context.Context, key string) (models.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(models.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempt indicates an expected call of GetLoginAttempt.
+func (mr *MockStorageReaderMockRecorder) GetLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempt", reflect.TypeOf((*MockStorageReader)(nil).GetLoginAttempt), ctx, key)
+}
+
+// GetOrderByID mocks base method.
+func (m *MockStorageReader) GetOrderByID(ctx context.Context, ID string) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", ctx, ID)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockStorageReaderMockRecorder) GetOrderByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockStorageReader)(nil).GetOrderByID), ctx, ID)
+}
+
+// GetOrderEventsByUID mocks base method.
+func (m *MockStorageReader) GetOrderEventsByUID(ctx context.Context, UID string, afterID int64, limit int) ([]models.OrderEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderEventsByUID", ctx, UID, afterID, limit)
+	ret0, _ := ret[0].([]models.OrderEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderEventsByUID indicates an expected call of GetOrderEventsByUID.
+func (mr *MockStorageReaderMockRecorder) GetOrderEventsByUID(ctx, UID, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderEventsByUID", reflect.TypeOf((*MockStorageReader)(nil).GetOrderEventsByUID), ctx, UID, afterID, limit)
+}
+
+// GetOrdersByUID mocks base method.
+func (m *MockStorageReader) GetOrdersByUID(ctx context.Context, UID string, filter models.OrderFilter) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersByUID", ctx, UID, filter)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersByUID indicates an expected call of GetOrdersByUID.
+func (mr *MockStorageReaderMockRecorder) GetOrdersByUID(ctx, UID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersByUID", reflect.TypeOf((*MockStorageReader)(nil).GetOrdersByUID), ctx, UID, filter)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockStorageReader) GetRefreshTokenByHash(ctx context.Context, hash string) (models.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", ctx, hash)
+	ret0, _ := ret[0].(models.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockStorageReaderMockRecorder) GetRefreshTokenByHash(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockStorageReader)(nil).GetRefreshTokenByHash), ctx, hash)
+}
+
+// GetTotalBalance mocks base method.
+func (m *MockStorageReader) GetTotalBalance(ctx context.Context) (models.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotalBalance", ctx)
+	ret0, _ := ret[0].(models.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotalBalance indicates an expected call of GetTotalBalance.
+func (mr *MockStorageReaderMockRecorder) GetTotalBalance(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalBalance", reflect.TypeOf((*MockStorageReader)(nil).GetTotalBalance), ctx)
+}
+
+// GetUnfinishedOrders mocks base method.
+func (m *MockStorageReader) GetUnfinishedOrders(ctx context.Context) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnfinishedOrders", ctx)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnfinishedOrders indicates an expected call of GetUnfinishedOrders.
+func (mr *MockStorageReaderMockRecorder) GetUnfinishedOrders(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnfinishedOrders", reflect.TypeOf((*MockStorageReader)(nil).GetUnfinishedOrders), ctx)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStorageReader) GetUserByID(ctx context.Context, ID string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, ID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStorageReaderMockRecorder) GetUserByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStorageReader)(nil).GetUserByID), ctx, ID)
+}
+
+// GetUserByLogin mocks base method.
+func (m *MockStorageReader) GetUserByLogin(ctx context.Context, user models.User) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByLogin", ctx, user)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByLogin indicates an expected call of GetUserByLogin.
+func (mr *MockStorageReaderMockRecorder) GetUserByLogin(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByLogin", reflect.TypeOf((*MockStorageReader)(nil).GetUserByLogin), ctx, user)
+}
+
+// GetWebhookByID mocks base method.
+func (m *MockStorageReader) GetWebhookByID(ctx context.Context, ID string) (models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookByID", ctx, ID)
+	ret0, _ := ret[0].(models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookByID indicates an expected call of GetWebhookByID.
+func (mr *MockStorageReaderMockRecorder) GetWebhookByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookByID", reflect.TypeOf((*MockStorageReader)(nil).GetWebhookByID), ctx, ID)
+}
+
+// GetWebhookDeliveriesByWebhookID mocks base method.
+func (m *MockStorageReader) GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveriesByWebhookID", ctx, webhookID, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveriesByWebhookID indicates an expected call of GetWebhookDeliveriesByWebhookID.
+func (mr *MockStorageReaderMockRecorder) GetWebhookDeliveriesByWebhookID(ctx, webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveriesByWebhookID", reflect.TypeOf((*MockStorageReader)(nil).GetWebhookDeliveriesByWebhookID), ctx, webhookID, limit)
+}
+
+// GetWebhookDeliveryByID mocks base method.
+func (m *MockStorageReader) GetWebhookDeliveryByID(ctx context.Context, ID string) (models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveryByID", ctx, ID)
+	ret0, _ := ret[0].(models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveryByID indicates an expected call of GetWebhookDeliveryByID.
+func (mr *MockStorageReaderMockRecorder) GetWebhookDeliveryByID(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveryByID", reflect.TypeOf((*MockStorageReader)(nil).GetWebhookDeliveryByID), ctx, ID)
+}
+
+// GetWebhooksByUID mocks base method.
+func (m *MockStorageReader) GetWebhooksByUID(ctx context.Context, UID string) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooksByUID", ctx, UID)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooksByUID indicates an expected call of GetWebhooksByUID.
+func (mr *MockStorageReaderMockRecorder) GetWebhooksByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooksByUID", reflect.TypeOf((*MockStorageReader)(nil).GetWebhooksByUID), ctx, UID)
+}
+
+// GetWithdrawalsByUID mocks base method.
+func (m *MockStorageReader) GetWithdrawalsByUID(ctx context.Context, UID string, filter models.WithdrawalFilter) ([]models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWithdrawalsByUID", ctx, UID, filter)
+	ret0, _ := ret[0].([]models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWithdrawalsByUID indicates an expected call of GetWithdrawalsByUID.
+func (mr *MockStorageReaderMockRecorder) GetWithdrawalsByUID(ctx, UID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWithdrawalsByUID", reflect.TypeOf((*MockStorageReader)(nil).GetWithdrawalsByUID), ctx, UID, filter)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStorageReader) IsTokenRevoked(ctx context.Context, JTI, UID string, generation int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, JTI, UID, generation)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStorageReaderMockRecorder) IsTokenRevoked(ctx, JTI, UID, generation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStorageReader)(nil).IsTokenRevoked), ctx, JTI, UID, generation)
+}
+
+// MockStorageWriter is a mock of StorageWriter interface.
+type MockStorageWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageWriterMockRecorder
+}
+
+// MockStorageWriterMockRecorder is the mock recorder for MockStorageWriter.
+type MockStorageWriterMockRecorder struct {
+	mock *MockStorageWriter
+}
+
+// NewMockStorageWriter creates a new mock instance.
+func NewMockStorageWriter(ctrl *gomock.Controller) *MockStorageWriter {
+	mock := &MockStorageWriter{ctrl: ctrl}
+	mock.recorder = &MockStorageWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageWriter) EXPECT() *MockStorageWriterMockRecorder {
+	return m.recorder
+}
+
+// AddIdempotencyKey mocks base method.
+func (m *MockStorageWriter) AddIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIdempotencyKey", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddIdempotencyKey indicates an expected call of AddIdempotencyKey.
+func (mr *MockStorageWriterMockRecorder) AddIdempotencyKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIdempotencyKey", reflect.TypeOf((*MockStorageWriter)(nil).AddIdempotencyKey), ctx, key)
+}
+
+// AddLedgerEntry mocks base method.
+func (m *MockStorageWriter) AddLedgerEntry(ctx context.Context, entry models.LedgerEntry) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLedgerEntry", ctx, entry)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLedgerEntry indicates an expected call of AddLedgerEntry.
+func (mr *MockStorageWriterMockRecorder) AddLedgerEntry(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLedgerEntry", reflect.TypeOf((*MockStorageWriter)(nil).AddLedgerEntry), ctx, entry)
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockStorageWriter) AddLoginFailure(ctx context.Context, key string, window time.Duration) (models.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, key, window)
+	ret0, _ := ret[0].(models.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockStorageWriterMockRecorder) AddLoginFailure(ctx, key, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockStorageWriter)(nil).AddLoginFailure), ctx, key, window)
+}
+
+// AddOrder mocks base method.
+func (m *MockStorageWriter) AddOrder(ctx context.Context, OrderID models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrder", ctx, OrderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrder indicates an expected call of AddOrder.
+func (mr *MockStorageWriterMockRecorder) AddOrder(ctx, OrderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockStorageWriter)(nil).AddOrder), ctx, OrderID)
+}
+
+// AddOrderEvent mocks base method.
+func (m *MockStorageWriter) AddOrderEvent(ctx context.Context, event models.OrderEvent) (models.OrderEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrderEvent", ctx, event)
+	ret0, _ := ret[0].(models.OrderEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddOrderEvent indicates an expected call of AddOrderEvent.
+func (mr *MockStorageWriterMockRecorder) AddOrderEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrderEvent", reflect.TypeOf((*MockStorageWriter)(nil).AddOrderEvent), ctx, event)
+}
+
+// AddRefreshToken mocks base method.
+func (m *MockStorageWriter) AddRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRefreshToken indicates an expected call of AddRefreshToken.
+func (mr *MockStorageWriterMockRecorder) AddRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefreshToken", reflect.TypeOf((*MockStorageWriter)(nil).AddRefreshToken), ctx, token)
+}
+
+// AddRevokedToken mocks base method.
+func (m *MockStorageWriter) AddRevokedToken(ctx context.Context, JTI string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRevokedToken", ctx, JTI, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRevokedToken indicates an expected call of AddRevokedToken.
+func (mr *MockStorageWriterMockRecorder) AddRevokedToken(ctx, JTI, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRevokedToken", reflect.TypeOf((*MockStorageWriter)(nil).AddRevokedToken), ctx, JTI, expiresAt)
+}
+
+// AddUser mocks base method.
+func (m *MockStorageWriter) AddUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockStorageWriterMockRecorder) AddUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockStorageWriter)(nil).AddUser), ctx, user)
+}
+
+// AddWebhook mocks base method.
+func (m *MockStorageWriter) AddWebhook(ctx context.Context, webhook models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhook", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhook indicates an expected call of AddWebhook.
+func (mr *MockStorageWriterMockRecorder) AddWebhook(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhook", reflect.TypeOf((*MockStorageWriter)(nil).AddWebhook), ctx, webhook)
+}
+
+// AddWebhookDelivery mocks base method.
+func (m *MockStorageWriter) AddWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhookDelivery indicates an expected call of AddWebhookDelivery.
+func (mr *MockStorageWriterMockRecorder) AddWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhookDelivery", reflect.TypeOf((*MockStorageWriter)(nil).AddWebhookDelivery), ctx, delivery)
+}
+
+// AddWithdrawal mocks base method.
+func (m *MockStorageWriter) AddWithdrawal(ctx context.Context, wth models.Withdrawal) (models.Withdrawal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWithdrawal", ctx, wth)
+	ret0, _ := ret[0].(models.Withdrawal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWithdrawal indicates an expected call of AddWithdrawal.
+func (mr *MockStorageWriterMockRecorder) AddWithdrawal(ctx, wth interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWithdrawal", reflect.TypeOf((*MockStorageWriter)(nil).AddWithdrawal), ctx, wth)
+}
+
+// AnonymizeUser mocks base method.
+func (m *MockStorageWriter) AnonymizeUser(ctx context.Context, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", ctx, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnonymizeUser indicates an expected call of AnonymizeUser.
+func (mr *MockStorageWriterMockRecorder) AnonymizeUser(ctx, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*MockStorageWriter)(nil).AnonymizeUser), ctx, ID)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStorageWriter) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", ctx, limit, lease)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStorageWriterMockRecorder) ClaimWebhookDeliveries(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStorageWriter)(nil).ClaimWebhookDeliveries), ctx, limit, lease)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockStorageWriter) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockStorageWriterMockRecorder) DeleteExpiredIdempotencyKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockStorageWriter)(nil).DeleteExpiredIdempotencyKeys), ctx)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStorageWriter) DeleteIdempotencyKey(ctx context.Context, UID, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, UID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStorageWriterMockRecorder) DeleteIdempotencyKey(ctx, UID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStorageWriter)(nil).DeleteIdempotencyKey), ctx, UID, key)
+}
+
+// DeleteOrderEventsBefore mocks base method.
+func (m *MockStorageWriter) DeleteOrderEventsBefore(ctx context.Context, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrderEventsBefore", ctx, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrderEventsBefore indicates an expected call of DeleteOrderEventsBefore.
+func (mr *MockStorageWriterMockRecorder) DeleteOrderEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrderEventsBefore", reflect.TypeOf((*MockStorageWriter)(nil).DeleteOrderEventsBefore), ctx, before)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStorageWriter) DeleteWebhook(ctx context.Context, UID, ID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, UID, ID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStorageWriterMockRecorder) DeleteWebhook(ctx, UID, ID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStorageWriter)(nil).DeleteWebhook), ctx, UID, ID)
+}
+
+// DeleteWebhookDeliveriesBefore mocks base method.
+func (m *MockStorageWriter) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookDeliveriesBefore", ctx, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookDeliveriesBefore indicates an expected call of DeleteWebhookDeliveriesBefore.
+func (mr *MockStorageWriterMockRecorder) DeleteWebhookDeliveriesBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookDeliveriesBefore", reflect.TypeOf((*MockStorageWriter)(nil).DeleteWebhookDeliveriesBefore), ctx, before)
+}
+
+// LockLogin mocks base method.
+func (m *MockStorageWriter) LockLogin(ctx context.Context, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockStorageWriterMockRecorder) LockLogin(ctx, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockStorageWriter)(nil).LockLogin), ctx, key, until)
+}
+
+// ReconcileBalanceByUID mocks base method.
+func (m *MockStorageWriter) ReconcileBalanceByUID(ctx context.Context, UID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileBalanceByUID", ctx, UID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileBalanceByUID indicates an expected call of ReconcileBalanceByUID.
+func (mr *MockStorageWriterMockRecorder) ReconcileBalanceByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileBalanceByUID", reflect.TypeOf((*MockStorageWriter)(nil).ReconcileBalanceByUID), ctx, UID)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockStorageWriter) ResetLoginAttempts(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockStorageWriterMockRecorder) ResetLoginAttempts(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockStorageWriter)(nil).ResetLoginAttempts), ctx, key)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockStorageWriter) RevokeRefreshToken(ctx context.Context, ID string, replacedBy *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, ID, replacedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockStorageWriterMockRecorder) RevokeRefreshToken(ctx, ID, replacedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockStorageWriter)(nil).RevokeRefreshToken), ctx, ID, replacedBy)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockStorageWriter) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockStorageWriterMockRecorder) RevokeRefreshTokenFamily(ctx, familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockStorageWriter)(nil).RevokeRefreshTokenFamily), ctx, familyID)
+}
+
+// RevokeRefreshTokensByUID mocks base method.
+func (m *MockStorageWriter) RevokeRefreshTokensByUID(ctx context.Context, UID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokensByUID", ctx, UID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokensByUID indicates an expected call of RevokeRefreshTokensByUID.
+func (mr *MockStorageWriterMockRecorder) RevokeRefreshTokensByUID(ctx, UID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokensByUID", reflect.TypeOf((*MockStorageWriter)(nil).RevokeRefreshTokensByUID), ctx, UID)
+}
+
+// SaveIdempotencyKeyResponse mocks base method.
+func (m *MockStorageWriter) SaveIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotencyKeyResponse", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotencyKeyResponse indicates an expected call of SaveIdempotencyKeyResponse.
+func (mr *MockStorageWriterMockRecorder) SaveIdempotencyKeyResponse(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotencyKeyResponse", reflect.TypeOf((*MockStorageWriter)(nil).SaveIdempotencyKeyResponse), ctx, key)
+}
+
+// UpdateOrder mocks base method.
+func (m *MockStorageWriter) UpdateOrder(ctx context.Context, order models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrder", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrder indicates an expected call of UpdateOrder.
+func (mr *MockStorageWriterMockRecorder) UpdateOrder(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrder", reflect.TypeOf((*MockStorageWriter)(nil).UpdateOrder), ctx, order)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockStorageWriter) UpdateUserPassword(ctx context.Context, ID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, ID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStorageWriterMockRecorder) UpdateUserPassword(ctx, ID, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStorageWriter)(nil).UpdateUserPassword), ctx, ID, password)
+}
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockStorageWriter) UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockStorageWriterMockRecorder) UpdateWebhookDelivery(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockStorageWriter)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
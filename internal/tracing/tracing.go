@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.uber.org/zap"
+)
+
+const serviceName = "gophermart"
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+var once sync.Once
+
+// New installs the global tracer provider with the exporter selected by
+// config.TracingExporter. With "none" spans are still created, so trace IDs
+// are propagated and logged, but they are not exported anywhere.
+func New(ctx context.Context) {
+	once.Do(func() {
+		cfg := config.Get()
+
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
+
+		opts := []sdktrace.TracerProviderOption{
+			sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+		}
+
+		switch cfg.TracingExporter {
+		case ExporterNone:
+		case ExporterStdout:
+			exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+			if err != nil {
+				zap.L().Fatal("tracing: create stdout exporter", zap.Error(err))
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		case ExporterOTLP:
+			clientOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.TracingOTLPEndpoint)}
+			if cfg.TracingOTLPInsecure {
+				clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+			}
+			exporter, err := otlptracehttp.New(ctx, clientOpts...)
+			if err != nil {
+				zap.L().Fatal("tracing: create otlp exporter", zap.Error(err))
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		default:
+			zap.L().Fatal("tracing: unknown exporter", zap.String("exporter", cfg.TracingExporter))
+		}
+
+		provider := sdktrace.NewTracerProvider(opts...)
+		otel.SetTracerProvider(provider)
+		addToShutdowner(provider)
+	})
+}
+
+// addToShutdowner flushes the spans left in the batcher.
+func addToShutdowner(provider *sdktrace.TracerProvider) {
+	shutdowner.Get().AddCloser(func(ctx context.Context) error {
+		return provider.Shutdown(ctx)
+	})
+}
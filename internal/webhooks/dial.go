@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const dialTimeout = 5 * time.Second
+
+// ErrAddressNotAllowed is returned for webhook endpoints resolving to an
+// address inside the service network.
+var ErrAddressNotAllowed = errors.New("webhook address is not public")
+
+// newTransport returns a transport that connects only to public addresses.
+// The address is checked after the name is resolved, right before the
+// connection, so DNS rebinding can't point a registered host at an internal
+// service. Proxies would hide the target address, so none are used.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return fmt.Errorf("%w: %s", ErrAddressNotAllowed, host)
+			}
+			return nil
+		},
+	}).DialContext
+	return transport
+}
+
+// nonPublicNets are the special-purpose ranges net.IP has no method for:
+// "this network", carrier-grade NAT and benchmarking.
+var nonPublicNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("198.18.0.0/15"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// isPublic rejects loopback, link-local, private, multicast, unspecified and
+// other special-purpose addresses.
+func isPublic(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() {
+		return false
+	}
+	for _, ipNet := range nonPublicNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,268 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/services/shutdowner"
+	"go.uber.org/zap"
+)
+
+const (
+	EventHeader     = "X-Gophermart-Event"
+	DeliveryHeader  = "X-Gophermart-Delivery"
+	TimestampHeader = "X-Gophermart-Timestamp"
+	SignatureHeader = "X-Gophermart-Signature"
+
+	dispatchWorkers  = 8
+	maxErrorLength   = 1024
+	maxResponseBytes = 64 << 10
+)
+
+// Storage is the outbox the dispatcher delivers from.
+type Storage interface {
+	GetWebhookByID(ctx context.Context, ID string) (models.Webhook, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+}
+
+// Dispatcher sends pending webhook deliveries. A failed delivery is retried
+// with exponential backoff and is dead-lettered after the last attempt.
+type Dispatcher struct {
+	storage     Storage
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	lease       time.Duration
+
+	stopped context.Context
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type Option func(d *Dispatcher)
+
+// WithHTTPClient replaces the client sending the requests, e.g. with the
+// client of an httptest server. The client is trusted to reach any address.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+func New(storage Storage, opts ...Option) *Dispatcher {
+	cfg := config.Get()
+	d := &Dispatcher{
+		storage: storage,
+		client: &http.Client{
+			Transport: newTransport(),
+			Timeout:   cfg.WebhookTimeout,
+			// a redirect is a failed delivery, the endpoint must be registered as is
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		interval:    cfg.WebhookDispatchInterval,
+		maxAttempts: cfg.WebhookMaxAttempts,
+		retryBase:   cfg.WebhookRetryBase,
+		retryMax:    cfg.WebhookRetryMax,
+		lease:       2 * cfg.WebhookTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Run dispatches due deliveries on every tick until shutdown. Deliveries in
+// flight keep using ctx, so they are not interrupted by the shutdown.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.stopped, d.stop = context.WithCancel(ctx)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.DispatchDue(ctx)
+			case <-d.stopped.Done():
+				return
+			}
+		}
+	}()
+	d.addToShutdowner()
+}
+
+// DispatchDue sends the due deliveries, a batch of parallel requests at a time.
+func (d *Dispatcher) DispatchDue(ctx context.Context) {
+	for {
+		if d.stopped != nil && d.stopped.Err() != nil {
+			return
+		}
+
+		deliveries, err := d.storage.ClaimWebhookDeliveries(ctx, dispatchWorkers, d.lease)
+		if err != nil {
+			zap.L().Warn("webhooks: claim deliveries", zap.Error(err))
+			return
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(len(deliveries))
+		for _, delivery := range deliveries {
+			go func(delivery models.WebhookDelivery) {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < dispatchWorkers {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	defer func() {
+		if p := recover(); p != nil {
+			zap.L().Warn("webhooks: recovered from panic", zap.Any("panic", p))
+		}
+	}()
+
+	webhook, err := d.storage.GetWebhookByID(ctx, delivery.WebhookID)
+	if err != nil {
+		// deleted webhooks take their deliveries with them
+		if !errors.Is(err, models.ErrWebhookNotFound) {
+			zap.L().Warn("webhooks: get webhook", zap.String("delivery", delivery.ID), zap.Error(err))
+		}
+		return
+	}
+
+	statusCode, err := d.send(ctx, webhook, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = nil
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = models.WebhookDeliveryDead
+	default:
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		delivery.LastError = &msg
+	}
+
+	if err = d.storage.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		zap.L().Warn("webhooks: update delivery", zap.String("delivery", delivery.ID), zap.Error(err))
+	}
+}
+
+// send posts the signed event, a response other than 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (*int, error) {
+	body, err := json.Marshal(struct {
+		ID        string                  `json:"id"`
+		Event     models.WebhookEventType `json:"event"`
+		CreatedAt time.Time               `json:"created_at"`
+		Data      models.RawJSON          `json:"data"`
+	}{delivery.ID, delivery.EventType, delivery.CreatedAt, delivery.Payload})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return &resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt: the base delay doubled
+// after every failed attempt, up to the maximum.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryBase
+	for i := 1; i < attempts && delay < d.retryMax; i++ {
+		delay *= 2
+	}
+	if delay > d.retryMax {
+		delay = d.retryMax
+	}
+	return delay
+}
+
+// Sign returns the signature of the request body: "sha256=" followed by the
+// hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should also reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// addToShutdowner stops dispatching on shutdown and waits for deliveries in flight.
+func (d *Dispatcher) addToShutdowner() {
+	shutdowner.Get().AddCloser(func(ctx context.Context) error {
+		d.stop()
+
+		done := make(chan struct{})
+		go func() {
+			d.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
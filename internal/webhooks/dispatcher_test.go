@@ -0,0 +1,188 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stsg/gophermart2/internal/config"
+	"github.com/stsg/gophermart2/internal/models"
+	"github.com/stsg/gophermart2/internal/storages"
+	"github.com/stsg/gophermart2/internal/storages/memory"
+)
+
+const testSecret = "0123456789abcdef"
+
+// newTestDispatcher returns a dispatcher sending to a receiver served by
+// handler, the storage and a pending delivery to the receiver.
+func newTestDispatcher(t *testing.T, cfg *config.Config, handler http.HandlerFunc) (*Dispatcher, storages.Storager, models.WebhookDelivery) {
+	t.Helper()
+	config.Set(cfg)
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := server.Client()
+	client.Timeout = cfg.WebhookTimeout
+
+	ctx := context.Background()
+	s := memory.New()
+	webhook := models.Webhook{ID: uuid.NewString(), UID: uuid.NewString(), URL: server.URL, Secret: testSecret}
+	if err := s.AddWebhook(ctx, webhook); err != nil {
+		t.Fatal(err)
+	}
+	delivery := models.WebhookDelivery{
+		ID:        uuid.NewString(),
+		WebhookID: webhook.ID,
+		UID:       webhook.UID,
+		EventType: models.WebhookEventOrderUpdated,
+		Payload:   models.RawJSON(`{"number":"79927398713"}`),
+		Status:    models.WebhookDeliveryPending,
+	}
+	if err := s.AddWebhookDelivery(ctx, delivery); err != nil {
+		t.Fatal(err)
+	}
+	return New(s, WithHTTPClient(client)), s, delivery
+}
+
+func getDelivery(t *testing.T, s storages.Storager, ID string) models.WebhookDelivery {
+	t.Helper()
+	delivery, err := s.GetWebhookDeliveryByID(context.Background(), ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+func TestDispatchDelivered(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan request, 1)
+	d, s, delivery := newTestDispatcher(t, config.Defaults(), func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Header, body}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	d.DispatchDue(context.Background())
+
+	req := <-requests
+	timestamp, err := strconv.ParseInt(req.header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if got, want := req.header.Get(SignatureHeader), Sign(testSecret, timestamp, req.body); got != want {
+		t.Fatalf("signature = %q, want %q", got, want)
+	}
+	if req.header.Get(DeliveryHeader) != delivery.ID || req.header.Get(EventHeader) != string(delivery.EventType) {
+		t.Fatalf("headers = %v", req.header)
+	}
+
+	got := getDelivery(t, s, delivery.ID)
+	if got.Status != models.WebhookDeliveryDelivered || got.Attempts != 1 || got.DeliveredAt == nil ||
+		got.LastStatusCode == nil || *got.LastStatusCode != http.StatusNoContent || got.LastError != nil {
+		t.Fatalf("delivery = %+v, want delivered on the first attempt", got)
+	}
+}
+
+func TestDispatchBackoff(t *testing.T) {
+	var release chan struct{}
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus *int
+	}{
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantStatus: func() *int { code := http.StatusBadGateway; return &code }(),
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			release = make(chan struct{})
+			cfg := config.Defaults()
+			cfg.WebhookTimeout = 50 * time.Millisecond
+			cfg.WebhookRetryBase = time.Minute
+			d, s, delivery := newTestDispatcher(t, cfg, tt.handler)
+			// let a hanging handler return before the receiver is closed
+			t.Cleanup(func() { close(release) })
+
+			before := time.Now()
+			d.DispatchDue(context.Background())
+			after := time.Now()
+
+			got := getDelivery(t, s, delivery.ID)
+			if got.Status != models.WebhookDeliveryPending || got.Attempts != 1 || got.LastError == nil || got.DeliveredAt != nil {
+				t.Fatalf("delivery = %+v, want pending after a failed attempt", got)
+			}
+			if (got.LastStatusCode == nil) != (tt.wantStatus == nil) ||
+				got.LastStatusCode != nil && *got.LastStatusCode != *tt.wantStatus {
+				t.Fatalf("last status code = %v, want %v", got.LastStatusCode, tt.wantStatus)
+			}
+			if got.NextAttemptAt.Before(before.Add(cfg.WebhookRetryBase)) || got.NextAttemptAt.After(after.Add(cfg.WebhookRetryBase)) {
+				t.Fatalf("next attempt at %v, want %v after the attempt", got.NextAttemptAt, cfg.WebhookRetryBase)
+			}
+		})
+	}
+}
+
+func TestDispatchDeadLetter(t *testing.T) {
+	var requests int32
+	cfg := config.Defaults()
+	cfg.WebhookMaxAttempts = 3
+	cfg.WebhookRetryBase = time.Millisecond
+	cfg.WebhookRetryMax = time.Millisecond
+	d, s, delivery := newTestDispatcher(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	for i := 0; i < cfg.WebhookMaxAttempts+2; i++ {
+		d.DispatchDue(context.Background())
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != int32(cfg.WebhookMaxAttempts) {
+		t.Fatalf("%d requests, want %d", n, cfg.WebhookMaxAttempts)
+	}
+	got := getDelivery(t, s, delivery.ID)
+	if got.Status != models.WebhookDeliveryDead || got.Attempts != cfg.WebhookMaxAttempts {
+		t.Fatalf("delivery = %+v, want dead after %d attempts", got, cfg.WebhookMaxAttempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{retryBase: time.Second, retryMax: 10 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}